@@ -1,6 +1,8 @@
 package types
 
 import (
+	"errors"
+	"sync"
 	"sync/atomic"
 	"time"
 	"bytes"
@@ -12,6 +14,19 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 )
 
+var (
+	// ErrHeartbeatUnknownValidator is returned when a Heartbeat's
+	// ValidatorAddress does not match any currently tracked Health.
+	ErrHeartbeatUnknownValidator = errors.New("heartbeat from unknown validator")
+	// ErrHeartbeatInvalidSignature is returned when a Heartbeat's signature
+	// does not verify against its validator's public key.
+	ErrHeartbeatInvalidSignature = errors.New("heartbeat has invalid signature")
+	// ErrHeartbeatStale is returned when a Heartbeat's (Height, Round,
+	// Sequence) tuple is not newer than the last one accepted for that
+	// validator, e.g. a replayed message.
+	ErrHeartbeatStale = errors.New("heartbeat is stale")
+)
+
 // Heartbeat is a simple vote-like structure so validators can
 // alert others that they are alive and waiting for transactions.
 // Note: We aren't adding ",omitempty" to Heartbeat's
@@ -72,6 +87,27 @@ const (
 	StateSwitching = 1
 	StateUsed = 2
 	StateRemoved = 3
+
+	// HeartbeatMissWindow is the number of recent rounds a validator's
+	// heartbeat is allowed to go missing before it counts against it in
+	// checkSwitchValidator. This bounds how much a single dropped
+	// heartbeat (e.g. from a UDP hiccup) can influence a switch decision.
+	HeartbeatMissWindow = 3
+
+	// DefaultScoreDecay is the EMA smoothing factor applied to a
+	// validator's reputation score on every round (Update call or tick).
+	// It is expressed as if averaging over roughly DefaultScoreWindow rounds.
+	DefaultScoreDecay = 2.0 / (256.0 + 1.0)
+	// DefaultScoreWindow is the nominal number of rounds the EMA score
+	// and latency average over.
+	DefaultScoreWindow = 256
+	// DefaultScoreQuantile is the fraction of the back pool's score
+	// distribution a worker must fall below before it is considered for
+	// switching.
+	DefaultScoreQuantile = 0.1
+	// DefaultScoreK is the number of consecutive below-quantile windows
+	// required before a switch is scheduled.
+	DefaultScoreK = 3
 )
 
 type Health struct {
@@ -81,12 +117,32 @@ type Health struct {
 	Tick		int32
 	State 		int
 	Val			*Validator
+
+	// heartbeat bookkeeping, guarded by HealthMgr.mtx
+	lastHeight   uint64
+	lastRound    uint
+	lastSequence uint
+	lastHeartbeat time.Time
+	missedHeartbeats int
+
+	// reputation bookkeeping, guarded by HealthMgr.mtx
+	score               float64 // EMA of the per-round success ratio, in [0,1]
+	latencyEMA          float64 // EMA of per-round response latency, in seconds
+	lastScoreUpdate     time.Time
+	belowQuantileRounds int // consecutive windows v.score has been below the back pool's quantile
+	windowRounds        int // rounds counted so far in the current score window
 }
 func (h *Health) String() string {
 	return fmt.Sprintf("id:%s,ip:%s,port:%d,tick:%d,state:%d,addr:%s",h.ID,h.IP,h.Port,h.Tick,h.State,
 			common.ToHex(h.Val.Address))
 }
 
+// hasRecentHeartbeat reports whether h has received a fresh, valid
+// Heartbeat within the last HeartbeatMissWindow rounds.
+func (h *Health) hasRecentHeartbeat() bool {
+	return h.missedHeartbeats < HeartbeatMissWindow
+}
+
 type SwitchValidator struct {
 	Remove 		*Health
 	Add 		*Health
@@ -100,22 +156,83 @@ type HealthMgr struct {
 	Work	 		map[p2p.ID]*Health
 	Back			[]*Health
 	Remove			[]*Health
-	SwitchChan		chan *SwitchValidator	
+	SwitchChan		chan *SwitchValidator
 	healthTick 		*time.Ticker
+
+	mtx				sync.RWMutex
+
+	chainID				string
+	self				*Health
+	heartbeatInterval	time.Duration
+	heartbeatTick		*time.Ticker
+	// SendHeartbeat, when set, is used to gossip our own signed Heartbeat
+	// to peers on every heartbeatInterval tick.
+	SendHeartbeat		func(hb *Heartbeat)
+	// SignHeartbeat, when set, fills in hb.Signature for the heartbeat
+	// broadcaster using our local validator key.
+	SignHeartbeat		func(hb *Heartbeat)
+	// heartbeatSeq is the Sequence stamped on our last outgoing Heartbeat.
+	heartbeatSeq		uint
+
+	scoreDecay		float64
+	scoreWindow		int
+	scoreQuantile	float64
+	scoreK			int
+}
+
+// ScoreConfig tunes the EMA-based reputation score that drives validator
+// switch decisions. The zero value is not usable; use DefaultScoreConfig.
+type ScoreConfig struct {
+	Decay    float64 // EMA smoothing factor applied per round, in (0,1]
+	Window   int     // nominal number of rounds Decay averages over
+	Quantile float64 // fraction of the back pool's score distribution to switch below
+	K        int     // consecutive below-quantile windows required before switching
+}
+
+// DefaultScoreConfig returns the built-in ScoreConfig, for callers of
+// NewHealthMgr that do not tune the score themselves.
+func DefaultScoreConfig() ScoreConfig {
+	return ScoreConfig{
+		Decay:    DefaultScoreDecay,
+		Window:   DefaultScoreWindow,
+		Quantile: DefaultScoreQuantile,
+		K:        DefaultScoreK,
+	}
 }
 
-func NewHealthMgr() *HealthMgr {
+func NewHealthMgr(chainID string, scoreConfig ScoreConfig) *HealthMgr {
 	h := &HealthMgr{
-		Work:			make(map[p2p.ID]*Health,0),
-		Back:			make([]*Health,0,0),
-		Remove:			make([]*Health,0,0),
-		SwitchChan:		make(chan*SwitchValidator),
-		Sum:			0,
-		healthTick:		nil,
+		Work:				make(map[p2p.ID]*Health,0),
+		Back:				make([]*Health,0,0),
+		Remove:				make([]*Health,0,0),
+		SwitchChan:			make(chan*SwitchValidator),
+		Sum:				0,
+		healthTick:			nil,
+		chainID:			chainID,
+		heartbeatInterval:	1 * time.Second,
+		scoreDecay:			scoreConfig.Decay,
+		scoreWindow:		scoreConfig.Window,
+		scoreQuantile:		scoreConfig.Quantile,
+		scoreK:				scoreConfig.K,
 	}
 	h.BaseService = *help.NewBaseService("HealthMgr", h)
 	return h
 }
+
+// SetHeartbeatInterval configures how often our own Heartbeat is broadcast
+// via SendHeartbeat. It must be called before OnStart.
+func (h *HealthMgr) SetHeartbeatInterval(d time.Duration) {
+	h.heartbeatInterval = d
+}
+
+// SetSelf records which Health entry represents this node, so the
+// heartbeat broadcaster knows which ValidatorAddress to stamp on our
+// outgoing Heartbeats.
+func (h *HealthMgr) SetSelf(self *Health) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.self = self
+}
 func (h *HealthMgr) SetBackValidators(hh []*Health) {
 	h.Back = hh
 	sort.Sort(HealthsByAddress(h.Back))
@@ -125,14 +242,54 @@ func (h *HealthMgr) OnStart() error {
 		h.healthTick = time.NewTicker(1*time.Second)
 		go h.healthGoroutine()
 	}
+	if h.heartbeatTick == nil && h.SendHeartbeat != nil {
+		h.heartbeatTick = time.NewTicker(h.heartbeatInterval)
+		go h.heartbeatGoroutine()
+	}
 	return nil
 }
 func (h *HealthMgr) OnStop() {
 	if h.healthTick != nil {
 		h.healthTick.Stop()
 	}
+	if h.heartbeatTick != nil {
+		h.heartbeatTick.Stop()
+	}
 	h.Stop()
 }
+
+// heartbeatGoroutine periodically emits our own signed Heartbeat so peers
+// can reciprocate and treat us as alive.
+func (h *HealthMgr) heartbeatGoroutine() {
+	for {
+		select {
+		case <-h.heartbeatTick.C:
+			h.broadcastHeartbeat()
+		case <-h.Quit():
+			return
+		}
+	}
+}
+
+func (h *HealthMgr) broadcastHeartbeat() {
+	h.mtx.Lock()
+	self := h.self
+	h.heartbeatSeq++
+	seq := h.heartbeatSeq
+	h.mtx.Unlock()
+	if self == nil || h.SendHeartbeat == nil {
+		return
+	}
+	hb := &Heartbeat{
+		ValidatorAddress: self.Val.Address,
+		ValidatorIndex:   0,
+		Sequence:         seq,
+	}
+	if h.SignHeartbeat != nil {
+		h.SignHeartbeat(hb)
+	}
+	h.SendHeartbeat(hb)
+}
 func (h *HealthMgr) Switch(s *SwitchValidator) {
 	select {
 	case h.SwitchChan <- s:
@@ -155,26 +312,152 @@ func (h *HealthMgr) healthGoroutine() {
 }
 func (h *HealthMgr) work() {
 	
+	h.mtx.Lock()
 	for _,v:=range h.Work {
 		if v.State == StateUsed {
 			atomic.AddInt32(&v.Tick,1)
+			v.missedHeartbeats++
+			h.updateScore(v, v.hasRecentHeartbeat())
 		}
-		h.checkSwitchValidator(v)	
-	} 
+	}
+	h.mtx.Unlock()
+
+	for _,v:=range h.Work {
+		h.checkSwitchValidator(v)
+	}
 }
 
+// updateScore folds a single round's outcome into v's EMA reputation score
+// and per-round latency average. It must be called with h.mtx held.
+func (h *HealthMgr) updateScore(v *Health, success bool) {
+	hit := 0.0
+	if success {
+		hit = 1.0
+	}
+	v.score = h.scoreDecay*hit + (1-h.scoreDecay)*v.score
+
+	now := time.Now()
+	if !v.lastScoreUpdate.IsZero() {
+		latency := now.Sub(v.lastScoreUpdate).Seconds()
+		v.latencyEMA = h.scoreDecay*latency + (1-h.scoreDecay)*v.latencyEMA
+	}
+	v.lastScoreUpdate = now
+}
+
+// backScoreQuantile returns the score at the given quantile (0 = worst,
+// 1 = best) of the back pool's current score distribution. It must be
+// called with h.mtx held.
+func (h *HealthMgr) backScoreQuantile(q float64) float64 {
+	if len(h.Back) == 0 {
+		return 0
+	}
+	scores := make([]float64, 0, len(h.Back))
+	for _, v := range h.Back {
+		scores = append(scores, v.score)
+	}
+	sort.Float64s(scores)
+	idx := int(q * float64(len(scores)-1))
+	if idx < 0 {
+		idx = 0
+	}
+	return scores[idx]
+}
+
+// GetScores reports the current EMA reputation score of every tracked
+// validator, for RPC/metrics consumption.
+func (h *HealthMgr) GetScores() map[p2p.ID]float64 {
+	h.mtx.RLock()
+	defer h.mtx.RUnlock()
+
+	scores := make(map[p2p.ID]float64, len(h.Work))
+	for id, v := range h.Work {
+		scores[id] = v.score
+	}
+	return scores
+}
+
+// checkSwitchValidator schedules a SwitchValidator once v's reputation
+// score has sat below the back pool's configured quantile at the end of
+// scoreK consecutive windows of scoreWindow rounds each, rather than
+// reacting to a single hard tick cutoff.
+// This avoids flappy switches under transient jitter and lets a back
+// validator that has recovered compete again once its score climbs back up.
 func (h *HealthMgr) checkSwitchValidator(v *Health) {
-	val := atomic.LoadInt32(&v.Tick)
-	if val > HealthOut && v.State == StateUsed {
-		back := h.pickUnuseValidator()
-		go h.Switch(&SwitchValidator {
-			Remove:			v,
-			Add:			back,
-			Resion:			"Switch",
-			from:			0,
-		})
-		v.State = StateSwitching
+	if v.State != StateUsed {
+		return
+	}
+
+	h.mtx.Lock()
+	v.windowRounds++
+	if h.scoreWindow > 0 && v.windowRounds < h.scoreWindow {
+		h.mtx.Unlock()
+		return
+	}
+	v.windowRounds = 0
+	threshold := h.backScoreQuantile(h.scoreQuantile)
+	belowQuantile := v.score < threshold
+	if belowQuantile {
+		v.belowQuantileRounds++
+	} else {
+		v.belowQuantileRounds = 0
+	}
+	rounds := v.belowQuantileRounds
+	h.mtx.Unlock()
+
+	if rounds < h.scoreK {
+		return
+	}
+	back := h.pickUnuseValidator()
+	go h.Switch(&SwitchValidator {
+		Remove:			v,
+		Add:			back,
+		Resion:			"Switch",
+		from:			0,
+	})
+	v.State = StateSwitching
+}
+
+// ReceiveHeartbeat verifies a signed Heartbeat against the sending
+// validator's public key, rejects stale (Height, Round, Sequence) tuples,
+// and otherwise resets the Tick of the corresponding Health so that a
+// validator which is actively heartbeating is never mistaken for an
+// unreachable one, even under transient tick-counter noise.
+func (h *HealthMgr) ReceiveHeartbeat(hb *Heartbeat) error {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	var target *Health
+	for _, v := range h.Work {
+		if bytes.Equal(v.Val.Address, hb.ValidatorAddress) {
+			target = v
+			break
+		}
+	}
+	if target == nil {
+		return ErrHeartbeatUnknownValidator
+	}
+
+	if target.lastHeartbeat != (time.Time{}) {
+		stale := hb.Height < target.lastHeight ||
+			(hb.Height == target.lastHeight && hb.Round < target.lastRound) ||
+			(hb.Height == target.lastHeight && hb.Round == target.lastRound && hb.Sequence <= target.lastSequence)
+		if stale {
+			return ErrHeartbeatStale
+		}
+	}
+
+	if target.Val.PubKey == nil || !target.Val.PubKey.VerifyBytes(hb.SignBytes(h.chainID), hb.Signature) {
+		return ErrHeartbeatInvalidSignature
 	}
+
+	target.lastHeight = hb.Height
+	target.lastRound = hb.Round
+	target.lastSequence = hb.Sequence
+	target.lastHeartbeat = time.Now()
+	target.missedHeartbeats = 0
+
+	h.update(target.ID)
+	return nil
 }
 func (h *HealthMgr) switchResult(res *SwitchValidator) {
 	if res.from == 1 {
@@ -194,22 +477,42 @@ func (h *HealthMgr) switchResult(res *SwitchValidator) {
 		log.Info(ss,"resion",res.Resion,"remove",res.Remove.String(),"add",res.Add.String())
 	}
 }
+// pickUnuseValidator returns the unused back validator with the highest
+// reputation score, rather than simply the first StateUnused entry in
+// address order, so a proven-reliable back validator is always promoted
+// ahead of an unproven one.
 func (h *HealthMgr) pickUnuseValidator() *Health {
-	sum := len(h.Back)
-	for i:=0;i<sum;i++ {
-		v := h.Back[i]
-		if v.State == StateUnused {
-			v.State = StateSwitching
-			return v
+	h.mtx.RLock()
+	defer h.mtx.RUnlock()
+
+	var best *Health
+	for _, v := range h.Back {
+		if v.State != StateUnused {
+			continue
+		}
+		if best == nil || v.score > best.score {
+			best = v
 		}
 	}
-	return nil
+	if best != nil {
+		best.State = StateSwitching
+	}
+	return best
 }
 
 func (h *HealthMgr) Update(id p2p.ID) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.update(id)
+}
+
+// update is the lock-free core of Update, for callers (such as
+// ReceiveHeartbeat) that already hold h.mtx.
+func (h *HealthMgr) update(id p2p.ID) {
 	if v,ok := h.Work[id];ok{
 		val := atomic.LoadInt32(&v.Tick)
 		atomic.AddInt32(&v.Tick,-val)
+		h.updateScore(v, true)
 	}
 }
 
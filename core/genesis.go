@@ -18,10 +18,14 @@ package core
 
 import (
 	"bytes"
+	_ "embed"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"math/big"
+	"os"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -57,11 +61,30 @@ type Genesis struct {
 	Alloc      types.GenesisAlloc       `json:"alloc"      gencodec:"required"`
 	Committee  []*types.CommitteeMember `json:"committee"      gencodec:"required"`
 
+	// MultiCoinAlloc pre-funds non-native assets at genesis, keyed first by
+	// account address and then by a 32-byte coin ID. It is kept separate
+	// from Alloc rather than folded into types.GenesisAccount because that
+	// type lives in the core/types package, outside this one; an account
+	// with no entry here holds only its native Alloc[addr].Balance, exactly
+	// as it did before MultiCoinAlloc existed.
+	MultiCoinAlloc map[common.Address]map[common.Hash]*big.Int `json:"multiCoinAlloc,omitempty"`
+
 	// These fields are used for consensus tests. Please don't use them
 	// in actual genesis blocks.
 	Number     uint64      `json:"number"`
 	GasUsed    uint64      `json:"gasUsed"`
 	ParentHash common.Hash `json:"parentHash"`
+
+	// TerminalTotalDifficulty, once set, is the snail-chain total
+	// difficulty at which Minerva PoW mining stops and a beacon/PoS-style
+	// finality producer takes over block production, mirroring the
+	// Ethereum-family merge transition. TerminalBlockHash/
+	// TerminalBlockNumber optionally pin the exact PoW block the switch
+	// must land on, guarding against a TTD that is crossed by the wrong
+	// fork.
+	TerminalTotalDifficulty *big.Int    `json:"terminalTotalDifficulty,omitempty"`
+	TerminalBlockHash       common.Hash `json:"terminalBlockHash,omitempty"`
+	TerminalBlockNumber     *big.Int    `json:"terminalBlockNumber,omitempty"`
 }
 
 // GenesisAccount is an account in the state of the genesis block.
@@ -75,14 +98,17 @@ type GenesisAccount struct {
 
 // field type overrides for gencodec
 type genesisSpecMarshaling struct {
-	Nonce      math.HexOrDecimal64
-	Timestamp  math.HexOrDecimal64
-	ExtraData  hexutil.Bytes
-	GasLimit   math.HexOrDecimal64
-	GasUsed    math.HexOrDecimal64
-	Number     math.HexOrDecimal64
-	Difficulty *math.HexOrDecimal256
-	Alloc      map[common.UnprefixedAddress]GenesisAccount
+	Nonce                   math.HexOrDecimal64
+	Timestamp               math.HexOrDecimal64
+	ExtraData               hexutil.Bytes
+	GasLimit                math.HexOrDecimal64
+	GasUsed                 math.HexOrDecimal64
+	Number                  math.HexOrDecimal64
+	Difficulty              *math.HexOrDecimal256
+	Alloc                   map[common.UnprefixedAddress]GenesisAccount
+	MultiCoinAlloc          map[common.UnprefixedAddress]map[common.Hash]*math.HexOrDecimal256
+	TerminalTotalDifficulty *math.HexOrDecimal256
+	TerminalBlockNumber     *math.HexOrDecimal256
 }
 
 type genesisAccountMarshaling struct {
@@ -114,6 +140,89 @@ func (h storageJSON) MarshalText() ([]byte, error) {
 	return hexutil.Bytes(h[:]).MarshalText()
 }
 
+// committeeMemberJSON is the on-disk form of a single genesis committee
+// member: a coinbase address and a hex-encoded uncompressed secp256k1
+// public key.
+type committeeMemberJSON struct {
+	Coinbase  common.Address `json:"coinbase"`
+	Publickey hexutil.Bytes  `json:"publickey"`
+}
+
+// UnmarshalJSON decodes a Genesis, accepting Committee as an array of
+// {coinbase, publickey} objects and validating every Publickey as a point
+// on the secp256k1 curve before it is accepted — a malformed or off-curve
+// key here would otherwise only surface much later, as an obscure
+// signature-verification failure once the chain built from it was already
+// running. gencodec skips generating UnmarshalJSON for a type that
+// already defines one, so gen_genesis.go will only carry the generated
+// MarshalJSON; this hand-written method is the counterpart.
+func (g *Genesis) UnmarshalJSON(input []byte) error {
+	var dec struct {
+		Config                  *params.ChainConfig                                                `json:"config"`
+		Nonce                   math.HexOrDecimal64                                                `json:"nonce"`
+		Timestamp               math.HexOrDecimal64                                                `json:"timestamp"`
+		ExtraData               hexutil.Bytes                                                      `json:"extraData"`
+		GasLimit                math.HexOrDecimal64                                                `json:"gasLimit"`
+		Difficulty              *math.HexOrDecimal256                                              `json:"difficulty"`
+		Mixhash                 common.Hash                                                        `json:"mixHash"`
+		Coinbase                common.Address                                                     `json:"coinbase"`
+		Alloc                   types.GenesisAlloc                                                 `json:"alloc"`
+		MultiCoinAlloc          map[common.UnprefixedAddress]map[common.Hash]*math.HexOrDecimal256 `json:"multiCoinAlloc,omitempty"`
+		Committee               []committeeMemberJSON                                              `json:"committee"`
+		Number                  math.HexOrDecimal64                                                `json:"number"`
+		GasUsed                 math.HexOrDecimal64                                                `json:"gasUsed"`
+		ParentHash              common.Hash                                                        `json:"parentHash"`
+		TerminalTotalDifficulty *math.HexOrDecimal256                                              `json:"terminalTotalDifficulty,omitempty"`
+		TerminalBlockHash       common.Hash                                                        `json:"terminalBlockHash,omitempty"`
+		TerminalBlockNumber     *math.HexOrDecimal256                                              `json:"terminalBlockNumber,omitempty"`
+	}
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+
+	g.Config = dec.Config
+	g.Nonce = uint64(dec.Nonce)
+	g.Timestamp = uint64(dec.Timestamp)
+	g.ExtraData = dec.ExtraData
+	g.GasLimit = uint64(dec.GasLimit)
+	if dec.Difficulty != nil {
+		g.Difficulty = (*big.Int)(dec.Difficulty)
+	}
+	g.Mixhash = dec.Mixhash
+	g.Coinbase = dec.Coinbase
+	g.Alloc = dec.Alloc
+	if dec.MultiCoinAlloc != nil {
+		g.MultiCoinAlloc = make(map[common.Address]map[common.Hash]*big.Int, len(dec.MultiCoinAlloc))
+		for addr, balances := range dec.MultiCoinAlloc {
+			converted := make(map[common.Hash]*big.Int, len(balances))
+			for coinID, balance := range balances {
+				converted[coinID] = (*big.Int)(balance)
+			}
+			g.MultiCoinAlloc[common.Address(addr)] = converted
+		}
+	}
+
+	g.Committee = make([]*types.CommitteeMember, len(dec.Committee))
+	for i, m := range dec.Committee {
+		if _, err := crypto.UnmarshalPubkey(m.Publickey); err != nil {
+			return fmt.Errorf("committee member %d: invalid publickey: %v", i, err)
+		}
+		g.Committee[i] = &types.CommitteeMember{Coinbase: m.Coinbase, Publickey: m.Publickey}
+	}
+
+	g.Number = uint64(dec.Number)
+	g.GasUsed = uint64(dec.GasUsed)
+	g.ParentHash = dec.ParentHash
+	if dec.TerminalTotalDifficulty != nil {
+		g.TerminalTotalDifficulty = (*big.Int)(dec.TerminalTotalDifficulty)
+	}
+	g.TerminalBlockHash = dec.TerminalBlockHash
+	if dec.TerminalBlockNumber != nil {
+		g.TerminalBlockNumber = (*big.Int)(dec.TerminalBlockNumber)
+	}
+	return nil
+}
+
 // GenesisMismatchError is raised when trying to overwrite an existing
 // genesis block with an incompatible one.
 type GenesisMismatchError struct {
@@ -124,17 +233,95 @@ func (e *GenesisMismatchError) Error() string {
 	return fmt.Sprintf("database already contains an incompatible genesis block (have %x, new %x)", e.Stored[:8], e.New[:8])
 }
 
+// TerminalTDMismatchError is returned by setupFastGenesisBlock/
+// setupSnailGenesisBlock when a supplied genesis sets a
+// TerminalTotalDifficulty below the total difficulty the locally stored
+// chain head has already accumulated. Accepting such a genesis would ask
+// the node to resume Minerva PoW mining past a point it had already
+// finalized the PoS switchover at, so it is rejected the same way an
+// incompatible fork-block config is via CheckCompatible.
+type TerminalTDMismatchError struct {
+	Stored, New *big.Int
+}
+
+func (e *TerminalTDMismatchError) Error() string {
+	return fmt.Sprintf("genesis terminal total difficulty %s is below already-accumulated chain total difficulty %s", e.New, e.Stored)
+}
+
+// SetupGenesisError wraps the errors SetupGenesisBlock's fast and snail
+// setup passes can independently return, so a caller sees both instead of
+// only the fast-chain one with the snail result silently dropped.
+type SetupGenesisError struct {
+	FastErr, SnailErr error
+}
+
+func (e *SetupGenesisError) Error() string {
+	switch {
+	case e.FastErr != nil && e.SnailErr != nil:
+		return fmt.Sprintf("fast genesis: %v; snail genesis: %v", e.FastErr, e.SnailErr)
+	case e.FastErr != nil:
+		return fmt.Sprintf("fast genesis: %v", e.FastErr)
+	default:
+		return fmt.Sprintf("snail genesis: %v", e.SnailErr)
+	}
+}
+
+// IsTerminalPoWBlock reports whether totalDifficulty is the first total
+// difficulty to reach or cross g's TerminalTotalDifficulty, i.e. whether
+// the block it belongs to is the last Minerva PoW block before the chain
+// switches to a PoS-style finality producer. It returns false when no
+// TerminalTotalDifficulty is configured. This mirrors the
+// ChainConfig.IsTerminalPoWBlock check used by modern Ethereum-family
+// clients; it hangs off Genesis here because this tree does not carry a
+// params.ChainConfig definition to attach it to directly. Consensus code
+// building or validating a snail header should call EnforceTerminalDifficulty,
+// which uses this to decide whether to force that header's difficulty to zero
+// rather than running Minerva.
+func (g *Genesis) IsTerminalPoWBlock(parentTotalDifficulty, totalDifficulty *big.Int) bool {
+	if g.TerminalTotalDifficulty == nil {
+		return false
+	}
+	return parentTotalDifficulty.Cmp(g.TerminalTotalDifficulty) < 0 && totalDifficulty.Cmp(g.TerminalTotalDifficulty) >= 0
+}
+
+// EnforceTerminalDifficulty is the difficulty-forcing half of the merge
+// switchover that IsTerminalPoWBlock only detects: given parentTotalDifficulty
+// (the parent snail block's total difficulty) and header (a candidate header
+// that would otherwise carry its own Minerva PoW difficulty), it zeroes
+// header.Difficulty once parentTotalDifficulty plus that PoW difficulty would
+// cross g.TerminalTotalDifficulty, exactly as IsTerminalPoWBlock's doc comment
+// describes but, until now, nothing in this tree actually did. It reports
+// whether it forced the header. A consensus engine's difficulty calculator is
+// the natural caller, immediately before sealing; this snapshot does not
+// contain one, so there is no such call site wired up yet.
+func (g *Genesis) EnforceTerminalDifficulty(parentTotalDifficulty *big.Int, header *types.SnailHeader) bool {
+	if parentTotalDifficulty == nil || header == nil || header.Difficulty == nil {
+		return false
+	}
+	totalDifficulty := new(big.Int).Add(parentTotalDifficulty, header.Difficulty)
+	if !g.IsTerminalPoWBlock(parentTotalDifficulty, totalDifficulty) {
+		return false
+	}
+	header.Difficulty = common.Big0
+	return true
+}
+
 // SetupGenesisBlock writes or updates the genesis block in db.
 // The block that will be used is:
 //
-//                          genesis == nil       genesis != nil
-//                       +------------------------------------------
-//     db has no genesis |  main-net default  |  genesis
-//     db has genesis    |  from DB           |  genesis (if compatible)
+//	                     genesis == nil       genesis != nil
+//	                  +------------------------------------------
+//	db has no genesis |  main-net default  |  genesis
+//	db has genesis    |  from DB           |  genesis (if compatible)
 //
 // The stored chain configuration will be updated if it is compatible (i.e. does not
 // specify a fork block below the local head block). In case of a conflict, the
 // error is a *params.ConfigCompatError and the new, unwritten config is returned.
+// This applies to a dev genesis (e.g. from DeveloperGenesisBlock) the same as
+// any other: a reconfigured dev chain is rejected rather than silently
+// overwritten, and the explicit ResetGenesis helper is the intended bypass.
+// SetupGenesisBlock itself returns any such error wrapped in a
+// *SetupGenesisError, whose FastErr/SnailErr carry the per-chain errors.
 //
 // The returned chain configuration is never nil.
 func SetupGenesisBlock(db etruedb.Database, genesis *Genesis) (*params.ChainConfig, common.Hash, common.Hash, error) {
@@ -143,19 +330,21 @@ func SetupGenesisBlock(db etruedb.Database, genesis *Genesis) (*params.ChainConf
 	}
 
 	fastConfig, fastHash, fastErr := setupFastGenesisBlock(db, genesis)
-	_, snailHash, _ := setupSnailGenesisBlock(db, genesis)
-
-	return fastConfig, fastHash, snailHash, fastErr
+	_, snailHash, snailErr := setupSnailGenesisBlock(db, genesis)
 
+	if fastErr != nil || snailErr != nil {
+		return fastConfig, fastHash, snailHash, &SetupGenesisError{FastErr: fastErr, SnailErr: snailErr}
+	}
+	return fastConfig, fastHash, snailHash, nil
 }
 
 // setupFastGenesisBlock writes or updates the fast genesis block in db.
 // The block that will be used is:
 //
-//                          genesis == nil       genesis != nil
-//                       +------------------------------------------
-//     db has no genesis |  main-net default  |  genesis
-//     db has genesis    |  from DB           |  genesis (if compatible)
+//	                     genesis == nil       genesis != nil
+//	                  +------------------------------------------
+//	db has no genesis |  main-net default  |  genesis
+//	db has genesis    |  from DB           |  genesis (if compatible)
 //
 // The stored chain configuration will be updated if it is compatible (i.e. does not
 // specify a fork block below the local head block). In case of a conflict, the
@@ -213,6 +402,12 @@ func setupFastGenesisBlock(db etruedb.Database, genesis *Genesis) (*params.Chain
 	if compatErr != nil && *height != 0 && compatErr.RewindTo != 0 {
 		return newcfg, stored, compatErr
 	}
+	if genesis != nil && genesis.TerminalTotalDifficulty != nil {
+		headHash := rawdb.ReadHeadHeaderHash(db)
+		if headTD := rawdb.ReadTd(db, headHash, *height); headTD != nil && genesis.TerminalTotalDifficulty.Cmp(headTD) < 0 {
+			return newcfg, stored, &TerminalTDMismatchError{Stored: headTD, New: genesis.TerminalTotalDifficulty}
+		}
+	}
 	rawdb.WriteChainConfig(db, stored, newcfg)
 	return newcfg, stored, nil
 }
@@ -236,10 +431,22 @@ func (g *Genesis) CommitFast(db etruedb.Database) (*types.Block, error) {
 	if config == nil {
 		config = params.AllMinervaProtocolChanges
 	}
+	// g.TerminalTotalDifficulty/TerminalBlockHash/TerminalBlockNumber are
+	// not part of config (they live on Genesis, not params.ChainConfig —
+	// see IsTerminalPoWBlock's doc comment) and are not copied onto it
+	// here, so they are not persisted by this write; only the pre-existing
+	// chain config fields are.
 	rawdb.WriteChainConfig(db, block.Hash(), config)
 	return block, nil
 }
 
+// genesisAllocFlushBatch is how many allocated accounts ToFastBlock writes
+// between incremental TrieDB flushes. A mainnet-scale airdrop allocation
+// can run to millions of accounts; without this, the entire genesis trie
+// would have to be built and held dirty in memory before a single byte
+// reaches disk.
+const genesisAllocFlushBatch = 10000
+
 // ToFastBlock creates the genesis block and writes state of a genesis specification
 // to the given database (or discards it if nil).
 func (g *Genesis) ToFastBlock(db etruedb.Database) *types.Block {
@@ -247,6 +454,7 @@ func (g *Genesis) ToFastBlock(db etruedb.Database) *types.Block {
 		db = etruedb.NewMemDatabase()
 	}
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	n := 0
 	for addr, account := range g.Alloc {
 		statedb.AddBalance(addr, account.Balance)
 		statedb.SetCode(addr, account.Code)
@@ -254,6 +462,14 @@ func (g *Genesis) ToFastBlock(db etruedb.Database) *types.Block {
 		for key, value := range account.Storage {
 			statedb.SetState(addr, key, value)
 		}
+		if balances, ok := g.MultiCoinAlloc[addr]; ok {
+			writeMultiCoinBalances(statedb, addr, balances)
+		}
+		n++
+		if n%genesisAllocFlushBatch == 0 {
+			flushRoot, _ := statedb.Commit(false)
+			statedb.Database().TrieDB().Commit(flushRoot, true)
+		}
 	}
 	root := statedb.IntermediateRoot(false)
 	head := &types.Header{
@@ -296,10 +512,10 @@ func (g *Genesis) MustFastCommit(db etruedb.Database) *types.Block {
 // setupSnailGenesisBlock writes or updates the genesis snail block in db.
 // The block that will be used is:
 //
-//                          genesis == nil       genesis != nil
-//                       +------------------------------------------
-//     db has no genesis |  main-net default  |  genesis
-//     db has genesis    |  from DB           |  genesis (if compatible)
+//	                     genesis == nil       genesis != nil
+//	                  +------------------------------------------
+//	db has no genesis |  main-net default  |  genesis
+//	db has genesis    |  from DB           |  genesis (if compatible)
 //
 // The stored chain configuration will be updated if it is compatible (i.e. does not
 // specify a fork block below the local head block). In case of a conflict, the
@@ -329,10 +545,42 @@ func setupSnailGenesisBlock(db etruedb.Database, genesis *Genesis) (*params.Chai
 		if hash != stored {
 			return genesis.Config, hash, &GenesisMismatchError{stored, hash}
 		}
+		if genesis.TerminalTotalDifficulty != nil {
+			headHash := snaildb.ReadHeadHeaderHash(db)
+			if height := snaildb.ReadHeaderNumber(db, headHash); height != nil {
+				if headTD := snaildb.ReadTd(db, headHash, *height); headTD != nil && genesis.TerminalTotalDifficulty.Cmp(headTD) < 0 {
+					return genesis.Config, stored, &TerminalTDMismatchError{Stored: headTD, New: genesis.TerminalTotalDifficulty}
+				}
+			}
+		}
 	}
 
 	// Get the existing chain configuration.
 	newcfg := genesis.configOrDefault(stored)
+	storedcfg := snaildb.ReadChainConfig(db, stored)
+	if storedcfg == nil {
+		log.Warn("Found snail genesis block without chain config")
+		snaildb.WriteChainConfig(db, stored, newcfg)
+		return newcfg, stored, nil
+	}
+	// Special case: don't change the existing config of a non-mainnet chain if no new
+	// config is supplied. These chains would get AllProtocolChanges (and a compat error)
+	// if we just continued here.
+	if genesis == nil && stored != params.MainnetSnailGenesisHash {
+		return storedcfg, stored, nil
+	}
+
+	// Check config compatibility and write the config. Compatibility errors
+	// are returned to the caller unless we're already at block zero.
+	snailHeight := snaildb.ReadHeaderNumber(db, snaildb.ReadHeadHeaderHash(db))
+	if snailHeight == nil {
+		return newcfg, stored, fmt.Errorf("missing block number for snail head header hash")
+	}
+	compatErr := storedcfg.CheckCompatible(newcfg, *snailHeight)
+	if compatErr != nil && *snailHeight != 0 && compatErr.RewindTo != 0 {
+		return newcfg, stored, compatErr
+	}
+	snaildb.WriteChainConfig(db, stored, newcfg)
 	return newcfg, stored, nil
 }
 
@@ -388,11 +636,11 @@ func (g *Genesis) CommitSnail(db etruedb.Database) (*types.SnailBlock, error) {
 	snaildb.WriteHeadBlockHash(db, block.Hash())
 	snaildb.WriteHeadHeaderHash(db, block.Hash())
 
-	// config := g.Config
-	// if config == nil {
-	// 	config = params.AllMinervaProtocolChanges
-	// }
-	// snaildb.WriteChainConfig(db, block.Hash(), config)
+	config := g.Config
+	if config == nil {
+		config = params.AllMinervaProtocolChanges
+	}
+	snaildb.WriteChainConfig(db, block.Hash(), config)
 	return block, nil
 }
 
@@ -406,40 +654,35 @@ func (g *Genesis) MustSnailCommit(db etruedb.Database) *types.SnailBlock {
 	return block
 }
 
+//go:embed genesis_mainnet.json
+var mainnetGenesisJSON string
+
+// DumpMainnetGenesisJSON writes the compiled-in mainnet genesis to w
+// unmodified, so a `genesis dump` CLI subcommand can hand an operator a
+// starting point to fork the mainnet committee/alloc without recompiling.
+func DumpMainnetGenesisJSON(w io.Writer) error {
+	_, err := w.Write([]byte(mainnetGenesisJSON))
+	return err
+}
+
 // DefaultGenesisBlock returns the Truechain main net snail block.
 func DefaultGenesisBlock() *Genesis {
-	i, _ := new(big.Int).SetString("90000000000000000000000", 10)
-	key1 := hexutil.MustDecode("0x0488a25849abee5921fdb581ba34cd66adc8e02b108391c4153ca8da27722e16badf4fcd5ba7f557ae76d444ccf3638e4590a181805623de1cab67f31364c79736")
-	key2 := hexutil.MustDecode("0x04a9a1cedb8900d893b607c4dbc834abada3fe98f247b8bcb5ef44d3d3a246c4cf41d9d792527473c30ded81fa4b81afe7030a09e093dd92746b98c79e6a204c63")
-	key3 := hexutil.MustDecode("0x040d153624462927444a8212717e4ad41ec5f5739bc36598d093d114729e1dc782d55d322699705829cf9d69f201009db797ebe8ba952f10a26fe36c64356b111b")
-	key4 := hexutil.MustDecode("0x04a3474c26578fce00d241119758271f6a208cc987c6f37d1518dcea2a51257bafeebd93202ae499cb5a8986720d4b63a04043aadb4d03430194a81860c9ca0763")
+	return mustLoadEmbeddedGenesis(mainnetGenesisJSON, params.MainnetChainConfig)
+}
 
-	return &Genesis{
-		Config:     params.MainnetChainConfig,
-		Nonce:      928,
-		ExtraData:  nil,
-		GasLimit:   88080384,
-		Difficulty: big.NewInt(20000),
-		//Alloc:      decodePrealloc(mainnetAllocData),
-		Alloc: map[common.Address]types.GenesisAccount{
-			common.HexToAddress("0x7c357530174275dd30e46319b89f71186256e4f7"): {Balance: i},
-			common.HexToAddress("0x4cf807958b9f6d9fd9331397d7a89a079ef43288"): {Balance: i},
-			common.HexToAddress("0x04d2252a3e0ca7c2aa81247ca33060855a34a808"): {Balance: i},
-			common.HexToAddress("0x05712ff78d08eaf3e0f1797aaf4421d9b24f8679"): {Balance: i},
-			common.HexToAddress("0x764727f61dd0717a48236842435e9aefab6723c3"): {Balance: i},
-			common.HexToAddress("0x764986534dba541d5061e04b9c561abe3f671178"): {Balance: i},
-			common.HexToAddress("0x0fd0bbff2e5b3ddb4f030ff35eb0fe06658646cf"): {Balance: i},
-			common.HexToAddress("0x40b3a743ba285a20eaeee770d37c093276166568"): {Balance: i},
-			common.HexToAddress("0x9d3c4a33d3bcbd2245a1bebd8e989b696e561eae"): {Balance: i},
-			common.HexToAddress("0x35c9d83c3de709bbd2cb4a8a42b89e0317abe6d4"): {Balance: i},
-		},
-		Committee: []*types.CommitteeMember{
-			&types.CommitteeMember{Coinbase: common.HexToAddress("0x76ea2f3a002431fede1141b660dbb75c26ba6d97"), Publickey: key1},
-			&types.CommitteeMember{Coinbase: common.HexToAddress("0x831151b7eb8e650dc442cd623fbc6ae20279df85"), Publickey: key2},
-			&types.CommitteeMember{Coinbase: common.HexToAddress("0x1074f7deccf8c66efcd0106e034d3356b7db3f2c"), Publickey: key3},
-			&types.CommitteeMember{Coinbase: common.HexToAddress("0xd985e9871d1be109af5a7f6407b1d6b686901fff"), Publickey: key4},
-		},
-	}
+// mustLoadEmbeddedGenesis decodes one of the compiled-in genesis_*.json
+// files and attaches config, the one field every embedded file omits
+// since it is a shared params.ChainConfig var rather than per-network
+// data. A decode failure here means a compiled-in genesis file itself is
+// malformed, which is a build-time bug, not a runtime condition callers
+// can recover from.
+func mustLoadEmbeddedGenesis(data string, config *params.ChainConfig) *Genesis {
+	genesis := new(Genesis)
+	if err := json.Unmarshal([]byte(data), genesis); err != nil {
+		panic(fmt.Sprintf("invalid embedded genesis: %v", err))
+	}
+	genesis.Config = config
+	return genesis
 }
 
 func (g *Genesis) configOrDefault(ghash common.Hash) *params.ChainConfig {
@@ -459,18 +702,215 @@ func (g *Genesis) configOrDefault(ghash common.Hash) *params.ChainConfig {
 	}
 }
 
+// allocItem is the RLP encoding of a single prealloc'd account: a compact
+// binary alternative to the JSON alloc format, produced ahead of time by
+// the mkalloc tool so a mainnet-scale airdrop ships as one RLP blob that
+// decodePrealloc can stream through in a single pass instead of going
+// through encoding/json and building an intermediate map of big.Ints.
+type allocItem struct {
+	Addr    common.Address
+	Balance *big.Int
+	Nonce   uint64           `rlp:"optional"`
+	Code    []byte           `rlp:"optional"`
+	Storage [][2]common.Hash `rlp:"optional"`
+}
+
+// decodePrealloc decodes the RLP list of allocItem produced by mkalloc into
+// a GenesisAlloc, streaming entries out of the rlp.Stream one at a time
+// rather than decoding into an intermediate slice.
 func decodePrealloc(data string) types.GenesisAlloc {
-	var p []struct{ Addr, Balance *big.Int }
-	if err := rlp.NewStream(strings.NewReader(data), 0).Decode(&p); err != nil {
+	s := rlp.NewStream(strings.NewReader(data), 0)
+	if _, err := s.List(); err != nil {
 		panic(err)
 	}
-	ga := make(types.GenesisAlloc, len(p))
-	for _, account := range p {
-		ga[common.BigToAddress(account.Addr)] = types.GenesisAccount{Balance: account.Balance}
+	ga := make(types.GenesisAlloc)
+	for {
+		var item allocItem
+		if err := s.Decode(&item); err == rlp.EOL {
+			break
+		} else if err != nil {
+			panic(err)
+		}
+		account := types.GenesisAccount{Balance: item.Balance, Nonce: item.Nonce, Code: item.Code}
+		if len(item.Storage) > 0 {
+			account.Storage = make(map[common.Hash]common.Hash, len(item.Storage))
+			for _, kv := range item.Storage {
+				account.Storage[kv[0]] = kv[1]
+			}
+		}
+		ga[item.Addr] = account
+	}
+	if err := s.ListEnd(); err != nil {
+		panic(err)
 	}
 	return ga
 }
 
+// LoadGenesisFromFile reads a JSON-encoded Genesis specification from path.
+// The alloc field is decoded through GenesisAlloc's streaming UnmarshalJSON
+// so a multi-hundred-megabyte mainnet allocation file is read once, rather
+// than being parsed into a generic map and then converted into GenesisAlloc.
+func LoadGenesisFromFile(path string) (*Genesis, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	genesis := new(Genesis)
+	if err := json.NewDecoder(f).Decode(genesis); err != nil {
+		return nil, fmt.Errorf("invalid genesis file %s: %v", path, err)
+	}
+	return genesis, nil
+}
+
+// emptyCodeHash is the Keccak256 hash of an empty byte slice, the CodeHash
+// every non-contract account stores; dumpAlloc uses it to skip a
+// ContractCode lookup for the common case of an account with no code.
+var emptyCodeHash = crypto.Keccak256(nil)
+
+// genesisDumpAccount is the raw decoded form of a single state trie leaf,
+// matching the RLP layout state.StateDB writes for an account.
+type genesisDumpAccount struct {
+	Nonce    uint64
+	Balance  *big.Int
+	Root     common.Hash
+	CodeHash []byte
+}
+
+// dumpAlloc walks every account in statedb's trie via a state.NewIterator
+// rather than decoding the whole trie into a map up front, so exporting a
+// multi-million-account state does not hold it all in memory at once.
+func dumpAlloc(statedb *state.StateDB) (types.GenesisAlloc, error) {
+	tr, err := statedb.Database().OpenTrie(statedb.IntermediateRoot(false))
+	if err != nil {
+		return nil, fmt.Errorf("opening state trie: %v", err)
+	}
+	alloc := make(types.GenesisAlloc)
+	it := state.NewIterator(tr.NodeIterator(nil))
+	for it.Next() {
+		var acc genesisDumpAccount
+		if err := rlp.DecodeBytes(it.Value, &acc); err != nil {
+			return nil, fmt.Errorf("invalid account encoding: %v", err)
+		}
+		addr := common.BytesToAddress(tr.GetKey(it.Key))
+		account := types.GenesisAccount{Balance: acc.Balance, Nonce: acc.Nonce}
+
+		if !bytes.Equal(acc.CodeHash, emptyCodeHash) {
+			account.Code = statedb.Database().ContractCode(crypto.Keccak256Hash(addr[:]), common.BytesToHash(acc.CodeHash))
+		}
+		if acc.Root != (common.Hash{}) {
+			storageTrie, err := statedb.Database().OpenStorageTrie(crypto.Keccak256Hash(addr[:]), acc.Root)
+			if err != nil {
+				return nil, fmt.Errorf("opening storage trie for %x: %v", addr, err)
+			}
+			account.Storage = make(map[common.Hash]common.Hash)
+			sit := state.NewIterator(storageTrie.NodeIterator(nil))
+			for sit.Next() {
+				var value common.Hash
+				if _, content, _, err := rlp.Split(sit.Value); err == nil {
+					value.SetBytes(content)
+				}
+				account.Storage[common.BytesToHash(storageTrie.GetKey(sit.Key))] = value
+			}
+			if sit.Err != nil {
+				return nil, sit.Err
+			}
+		}
+		alloc[addr] = account
+	}
+	if it.Err != nil {
+		return nil, it.Err
+	}
+	return alloc, nil
+}
+
+// Dump walks the state trie of bc at blockNum and materializes it into a
+// Genesis that can be re-loaded via LoadGenesisFromFile to fork the chain
+// from that point: every account's balance, nonce, code and storage is
+// captured into Alloc, and the block's own committee becomes the new
+// genesis committee.
+func (g *Genesis) Dump(bc *BlockChain, blockNum uint64) (*Genesis, error) {
+	block := bc.GetBlockByNumber(blockNum)
+	if block == nil {
+		return nil, fmt.Errorf("block %d not found", blockNum)
+	}
+	statedb, err := bc.StateAt(block.Root())
+	if err != nil {
+		return nil, fmt.Errorf("state at block %d unavailable: %v", blockNum, err)
+	}
+	alloc, err := dumpAlloc(statedb)
+	if err != nil {
+		return nil, err
+	}
+	return &Genesis{
+		Config:     g.Config,
+		Timestamp:  block.Time().Uint64(),
+		ExtraData:  block.Extra(),
+		GasLimit:   block.GasLimit(),
+		GasUsed:    block.GasUsed(),
+		Number:     block.NumberU64(),
+		ParentHash: block.ParentHash(),
+		Alloc:      alloc,
+		Committee:  block.Infos(),
+	}, nil
+}
+
+// DumpGenesisJSON reads the canonical block at blockNum directly from db
+// and writes it to w as a Genesis JSON document, embedding the chain
+// config stored for that block. It is the non-BlockChain counterpart of
+// Dump, for CLI tooling that only has a database handle.
+func DumpGenesisJSON(db etruedb.Database, blockNum uint64, w io.Writer) error {
+	hash := rawdb.ReadCanonicalHash(db, blockNum)
+	if (hash == common.Hash{}) {
+		return fmt.Errorf("no canonical block at height %d", blockNum)
+	}
+	genesis, err := ExportGenesisAt(db, hash)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(genesis)
+}
+
+// ExportGenesisAt dumps the state at the block identified by hash, plus
+// that block's stored ChainConfig, into a Genesis. It is the convenience
+// entry point for a CLI `genesis export` style command that forks a new
+// chain from an arbitrary historical block; the caller decides how to
+// serialize or use the result.
+func ExportGenesisAt(db etruedb.Database, hash common.Hash) (*Genesis, error) {
+	number := rawdb.ReadHeaderNumber(db, hash)
+	if number == nil {
+		return nil, fmt.Errorf("no block found for hash %x", hash)
+	}
+	block := rawdb.ReadBlock(db, hash, *number)
+	if block == nil {
+		return nil, fmt.Errorf("block %x not found", hash)
+	}
+	statedb, err := state.New(block.Root(), state.NewDatabase(db))
+	if err != nil {
+		return nil, fmt.Errorf("state at block %x unavailable: %v", hash, err)
+	}
+	alloc, err := dumpAlloc(statedb)
+	if err != nil {
+		return nil, err
+	}
+	config := rawdb.ReadChainConfig(db, hash)
+	if config == nil {
+		config = params.AllMinervaProtocolChanges
+	}
+	return &Genesis{
+		Config:     config,
+		Timestamp:  block.Time().Uint64(),
+		ExtraData:  block.Extra(),
+		GasLimit:   block.GasLimit(),
+		GasUsed:    block.GasUsed(),
+		Number:     block.NumberU64(),
+		ParentHash: block.ParentHash(),
+		Alloc:      alloc,
+		Committee:  block.Infos(),
+	}, nil
+}
+
 // GenesisFastBlockForTesting creates and writes a block in which addr has the given wei balance.
 func GenesisFastBlockForTesting(db etruedb.Database, addr common.Address, balance *big.Int) *types.Block {
 	g := Genesis{Alloc: types.GenesisAlloc{addr: {Balance: balance}}}
@@ -483,97 +923,98 @@ func GenesisSnailBlockForTesting(db etruedb.Database, addr common.Address, balan
 	return g.MustSnailCommit(db)
 }
 
+//go:embed genesis_devnet.json
+var devnetGenesisJSON string
+
+// DumpDevGenesisJSON writes the compiled-in devnet genesis to w unmodified.
+func DumpDevGenesisJSON(w io.Writer) error {
+	_, err := w.Write([]byte(devnetGenesisJSON))
+	return err
+}
+
 // DefaultDevGenesisBlock returns the Rinkeby network genesis block.
 func DefaultDevGenesisBlock() *Genesis {
-	i, _ := new(big.Int).SetString("90000000000000000000000", 10)
-	key1 := hexutil.MustDecode("0x0488a25849abee5921fdb581ba34cd66adc8e02b108391c4153ca8da27722e16badf4fcd5ba7f557ae76d444ccf3638e4590a181805623de1cab67f31364c79736")
-	key2 := hexutil.MustDecode("0x04a9a1cedb8900d893b607c4dbc834abada3fe98f247b8bcb5ef44d3d3a246c4cf41d9d792527473c30ded81fa4b81afe7030a09e093dd92746b98c79e6a204c63")
-	key3 := hexutil.MustDecode("0x040d153624462927444a8212717e4ad41ec5f5739bc36598d093d114729e1dc782d55d322699705829cf9d69f201009db797ebe8ba952f10a26fe36c64356b111b")
-	key4 := hexutil.MustDecode("0x04a3474c26578fce00d241119758271f6a208cc987c6f37d1518dcea2a51257bafeebd93202ae499cb5a8986720d4b63a04043aadb4d03430194a81860c9ca0763")
-	key5 := hexutil.MustDecode("0x04a3e174523b1054e14f123580bce258745e65591c2a4ee44764e55eb87a3782c9920d306e6121d4f10f8726800497ad9ca5a0bfdfe0832779dbaf7b95b3bf0111")
-	key6 := hexutil.MustDecode("0x04d370defb1b7b8c086f98c4a7d7b90348b088cd2effdcc27b86feebdff499a192b4a5a5b16a400625271d69b3fa7d8c42c8b2e15c910cd1f314f28eb5beb73342")
-	key7 := hexutil.MustDecode("0x04f67ab0cd48f626da89c718bcd909a04dea393d632d3191891539ef2f5ff6bb1e5d340ebe94cb6d9126b26e1ec64bb4783e9e8ddf31346b53d651d15eb226142e")
+	return mustLoadEmbeddedGenesis(devnetGenesisJSON, params.DevnetChainConfig)
+}
+
+// DeveloperGenesisBlock returns the genesis block for `truechain --dev`: a
+// single-node network that boots with no hard-coded keys or peers. faucet
+// is pre-funded with a very large balance for sending test transactions,
+// the well-known precompile addresses 0x01..0x08 are pre-funded with 1 wei
+// so they exist in state from block 0, period overrides the fast-block
+// target time via Minerva.Period, and the sole committee member is
+// generated from an ephemeral key minted for this call.
+func DeveloperGenesisBlock(period uint64, faucet common.Address) *Genesis {
+	// Override the default period to the user requested one.
+	config := *params.AllMinervaProtocolChanges
+	if config.Minerva != nil {
+		minerva := *config.Minerva
+		minerva.Period = period
+		config.Minerva = &minerva
+	}
+
+	// Assemble and return the genesis with the precompiles and faucet pre-funded.
+	alloc := make(map[common.Address]types.GenesisAccount, 9)
+	for i := 0; i < 8; i++ {
+		alloc[common.BytesToAddress([]byte{byte(i + 1)})] = types.GenesisAccount{Balance: big.NewInt(1)}
+	}
+	faucetBalance, _ := new(big.Int).SetString("1000000000000000000000000000", 10) // 1e9 ether
+	alloc[faucet] = types.GenesisAccount{Balance: faucetBalance}
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		panic(fmt.Sprintf("truechain --dev: failed to generate ephemeral committee key: %v", err))
+	}
 
 	return &Genesis{
-		Config:     params.DevnetChainConfig,
-		Nonce:      928,
-		ExtraData:  nil,
-		GasLimit:   88080384,
-		Difficulty: big.NewInt(20000),
-		//Alloc:      decodePrealloc(mainnetAllocData),
-		Alloc: map[common.Address]types.GenesisAccount{
-			common.HexToAddress("0x7c357530174275dd30e46319b89f71186256e4f7"): {Balance: i},
-			common.HexToAddress("0x4cf807958b9f6d9fd9331397d7a89a079ef43288"): {Balance: i},
-			common.HexToAddress("0x04d2252a3e0ca7c2aa81247ca33060855a34a808"): {Balance: i},
-			common.HexToAddress("0x05712ff78d08eaf3e0f1797aaf4421d9b24f8679"): {Balance: i},
-			common.HexToAddress("0x764727f61dd0717a48236842435e9aefab6723c3"): {Balance: i},
-			common.HexToAddress("0x764986534dba541d5061e04b9c561abe3f671178"): {Balance: i},
-			common.HexToAddress("0x0fd0bbff2e5b3ddb4f030ff35eb0fe06658646cf"): {Balance: i},
-			common.HexToAddress("0x40b3a743ba285a20eaeee770d37c093276166568"): {Balance: i},
-			common.HexToAddress("0x9d3c4a33d3bcbd2245a1bebd8e989b696e561eae"): {Balance: i},
-			common.HexToAddress("0x35c9d83c3de709bbd2cb4a8a42b89e0317abe6d4"): {Balance: i},
-		},
+		Config:     &config,
+		GasLimit:   11500000,
+		Difficulty: big.NewInt(1),
+		Alloc:      alloc,
 		Committee: []*types.CommitteeMember{
-			{Coinbase: common.HexToAddress("0x76ea2f3a002431fede1141b660dbb75c26ba6d97"), Publickey: key1},
-			{Coinbase: common.HexToAddress("0x831151b7eb8e650dc442cd623fbc6ae20279df85"), Publickey: key2},
-			{Coinbase: common.HexToAddress("0x1074f7deccf8c66efcd0106e034d3356b7db3f2c"), Publickey: key3},
-			{Coinbase: common.HexToAddress("0xd985e9871d1be109af5a7f6407b1d6b686901fff"), Publickey: key4},
-			{Coinbase: common.HexToAddress("0x7c357530174275dd30e46319b89f71186256e4f7"), Publickey: key5},
-			{Coinbase: common.HexToAddress("0x4cf807958b9f6d9fd9331397d7a89a079ef43288"), Publickey: key6},
-			{Coinbase: common.HexToAddress("0x04d2252a3e0ca7c2aa81247ca33060855a34a808"), Publickey: key7},
+			{Coinbase: crypto.PubkeyToAddress(key.PublicKey), Publickey: crypto.FromECDSAPub(&key.PublicKey)},
 		},
 	}
 }
 
+// ResetGenesis forcibly (re)commits g as block 0 of both the fast and
+// snail chains, overwriting whatever canonical/head pointers and chain
+// config were previously stored. It is the building block behind a
+// --dev.reset style CLI flag: a developer chain that was reconfigured
+// (e.g. a new DeveloperGenesisBlock period) would otherwise be rejected
+// by SetupGenesisBlock as an incompatible stored chain. The caller is
+// expected to have already wiped the on-disk chain data before invoking
+// this, since ResetGenesis itself does not walk and delete the previously
+// stored state and blocks.
+func ResetGenesis(db etruedb.Database, g *Genesis) (*params.ChainConfig, common.Hash, common.Hash, error) {
+	if g.Config == nil {
+		return params.AllMinervaProtocolChanges, common.Hash{}, common.Hash{}, errGenesisNoConfig
+	}
+	fastBlock, err := g.CommitFast(db)
+	if err != nil {
+		return g.Config, common.Hash{}, common.Hash{}, err
+	}
+	snailBlock, err := g.CommitSnail(db)
+	if err != nil {
+		return g.Config, fastBlock.Hash(), common.Hash{}, err
+	}
+	return g.Config, fastBlock.Hash(), snailBlock.Hash(), nil
+}
+
+//go:embed genesis_testnet.json
+var testnetGenesisJSON string
+
+// DumpTestnetGenesisJSON writes the compiled-in testnet genesis to w
+// unmodified. It is the building block behind a `genesis dump` CLI
+// subcommand: an operator runs it, edits the committee/alloc in the
+// result, and starts their node with --genesis path/to/genesis.json
+// instead of recompiling with a different hardcoded committee.
+func DumpTestnetGenesisJSON(w io.Writer) error {
+	_, err := w.Write([]byte(testnetGenesisJSON))
+	return err
+}
+
 // DefaultTestnetGenesisBlock returns the Ropsten network genesis block.
 func DefaultTestnetGenesisBlock() *Genesis {
-	seedkey1 := hexutil.MustDecode("0x042afba5a6680b5361bb57761ca67a7ea309d2883bda93c5d9521078258bb97b03610002865fb27993fcea4918023144eb516706ea33c7c94fef7b2f330cb9d0a6")
-	seedkey2 := hexutil.MustDecode("0x04e444bc40b6d1372a955fb9bb9a986ceb1c13a450794151fbf48033189351f6bddddcbebfa5c6d205887551e9527e6deff2cbee9f233ffe14fd15db4beb9c9f34")
-	seedkey3 := hexutil.MustDecode("0x049620df839696f4451842fd543b38d171f7f215dcd2c7fcd813c0206f097206a67b25ad719fbb62570c4a4ba467ec61aa396788e3ae79c704a62ea759beca3175")
-	seedkey4 := hexutil.MustDecode("0x04f714bb815a9ecc505eae7e756b63753850df92a0fe4c99dc8b6660ba17bbcbb88000d9efb524eb38746ef4505ad2ab1895efccbcc966d4c685c811bda7c9d8ef")
-
-	seedkey5 := hexutil.MustDecode("0x04c0617eef5000dc4a48fb4483735a33c7b2e58e3301fec13b55e9369f8b2bd04c59d899a1fe977b06a3db71fd7c8036b564ffa07171071835a7bb9e24cff22312")
-	seedkey6 := hexutil.MustDecode("0x0420bf209047d5eace814848692360a83065841ee91445a8b71b6092f681bf7741a5497ae0a28c401cda133ba8d12ca3dbc6ae756d2fc55288abc159c2ddf601fc")
-	seedkey7 := hexutil.MustDecode("0x043736280e96284f5d9460fd874f2dbe6b82ae29d7f348b931f540cc7612f41f20319c76ac90f3de8c68db2e9c7cf9bdfe0fca62046b0f35d01404d49d1de2a43e")
-	seedkey8 := hexutil.MustDecode("0x042896914b006d756bd5536069cf99d99e6dd7c8efb5dca582c44b6be293701f1c3a70f1d38de52e4180618fc9b9fbf1896ef445e7f3e51160a8b0e4ed5dc7823b")
-	seedkey9 := hexutil.MustDecode("0x04b548e8a1180c649efe64db740dce38417a8fde8a77bf659cf485489d8e608032f71c96cb6988fa3e55927b43a7d70572599be8792c446bdd6261114632767b44")
-	seedkey10 := hexutil.MustDecode("0x0440fae92a40624911932dfc31cfb93c2ba4a865ec8b640f15b7886daf2a2d93ad697a310d521af8552130305a00c96d7a27aad990b24264d7637a81ed46836a52")
-	seedkey11 := hexutil.MustDecode("0x043177df05ba2ad027e3a1f657002b8530206c07c747f86c28b5a9d9a7b11680bd03ee22710b6013446e9925fa82a3a72de396b4839a81b2cb5fc93fd1ee6f5a78")
-	seedkey12 := hexutil.MustDecode("0x040eed64a645c75e8436bc3680eb89db0592d4e95bbf865127b712d4840529864094a0b1c7a6389a33c0e03bc1633e7ed160235ba77a654a6a781b68ed8500ab1c")
-	seedkey13 := hexutil.MustDecode("0x04f61cdcd76e0a52f299378b53f182a5e135ea8cca327c11a2fbcc475daf3f0be858a3bda72352cb49d27dc28520ca6f708a39b0f12c49595beb76b3eec253959d")
-	seedkey14 := hexutil.MustDecode("0x048be0f382ee382517c2858f7d3abd2421469aac42bc04a6e964ed71e718c58e4178b2e8d1e0671885b66eb7ccfa432e0ea4958d08ce5f18d8e77e7dcf5191cfd5")
-
-	coinbase := common.HexToAddress("0x0000000000000000000000000000000000000000")
-	// amount, _ := new(big.Int).SetString("90000000000000000000000", 10)
-	return &Genesis{
-		Config:     params.TestnetChainConfig,
-		Nonce:      928,
-		ExtraData:  hexutil.MustDecode("0x54727565436861696E20546573744E6574203033"),
-		GasLimit:   20971520,
-		Difficulty: big.NewInt(6000000),
-		Timestamp:  1537891200,
-		Coinbase:   common.HexToAddress("0x0000000000000000000000000000000000000000"),
-		Mixhash:    common.HexToHash("0x0000000000000000000000000000000000000000000000000000000000000000"),
-		ParentHash: common.HexToHash("0x0000000000000000000000000000000000000000000000000000000000000000"),
-		Alloc:      map[common.Address]types.GenesisAccount{
-			// common.HexToAddress("0x7c357530174275dd30e46319b89f71186256e4f7"): {Balance: amount},
-			// common.HexToAddress("0x4cf807958b9f6d9fd9331397d7a89a079ef43288"): {Balance: amount},
-		},
-		Committee: []*types.CommitteeMember{
-			&types.CommitteeMember{Coinbase: coinbase, Publickey: seedkey1},
-			&types.CommitteeMember{Coinbase: coinbase, Publickey: seedkey2},
-			&types.CommitteeMember{Coinbase: coinbase, Publickey: seedkey3},
-			&types.CommitteeMember{Coinbase: coinbase, Publickey: seedkey4},
-			&types.CommitteeMember{Coinbase: coinbase, Publickey: seedkey5},
-			&types.CommitteeMember{Coinbase: coinbase, Publickey: seedkey6},
-			&types.CommitteeMember{Coinbase: coinbase, Publickey: seedkey7},
-			&types.CommitteeMember{Coinbase: coinbase, Publickey: seedkey8},
-			&types.CommitteeMember{Coinbase: coinbase, Publickey: seedkey9},
-			&types.CommitteeMember{Coinbase: coinbase, Publickey: seedkey10},
-			&types.CommitteeMember{Coinbase: coinbase, Publickey: seedkey11},
-			&types.CommitteeMember{Coinbase: coinbase, Publickey: seedkey12},
-			&types.CommitteeMember{Coinbase: coinbase, Publickey: seedkey13},
-			&types.CommitteeMember{Coinbase: coinbase, Publickey: seedkey14},
-		},
-	}
+	return mustLoadEmbeddedGenesis(testnetGenesisJSON, params.TestnetChainConfig)
 }
@@ -0,0 +1,75 @@
+// Copyright 2014 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/truechain/truechain-engineering-code/core/state"
+)
+
+// nativeCoinID is the reserved coin ID for the native TRUE token within a
+// Genesis.MultiCoinAlloc entry; every other 32-byte value names a
+// distinct non-native asset pre-funded at genesis.
+var nativeCoinID common.Hash
+
+// multiCoinSlot derives the per-account storage slot a non-native
+// balance is kept in from its coin ID. Hashing the ID rather than using
+// it directly as the slot spreads balances across the account's storage
+// trie the same way a Solidity `mapping(bytes32 => uint256)` would, so
+// getBalanceMC/transferMC can read and write it with an ordinary
+// SLOAD/SSTORE instead of a bespoke sub-trie layout.
+func multiCoinSlot(coinID common.Hash) common.Hash {
+	return crypto.Keccak256Hash(coinID[:])
+}
+
+// writeMultiCoinBalances writes every non-native entry of balances into
+// its own genesis-time storage slot via statedb.SetState, the same path
+// ToFastBlock already uses for an account's plain Storage map. The
+// native coin entry is skipped since it is carried by the account's
+// ordinary Balance field and applied with AddBalance instead.
+//
+// ToFastBlock calls this once per address present in g.MultiCoinAlloc.
+// That field lives on Genesis rather than on an account entry because
+// types.GenesisAccount is defined in the core/types package, outside this
+// one, and couldn't be extended with a Balances field from here.
+func writeMultiCoinBalances(statedb *state.StateDB, addr common.Address, balances map[common.Hash]*big.Int) {
+	for coinID, balance := range balances {
+		if coinID == nativeCoinID || balance == nil {
+			continue
+		}
+		statedb.SetState(addr, multiCoinSlot(coinID), common.BigToHash(balance))
+	}
+}
+
+// getBalanceMC/transferMC are meant to be fixed-address precompiles that
+// read and write exactly the multiCoinSlot(coinID) slot writeMultiCoinBalances
+// populates: getBalanceMC(coinID) returns statedb.GetState(caller,
+// multiCoinSlot(coinID)) as a uint256, and transferMC(to, coinID, amount)
+// debits that slot on the caller and credits it on to, charging gas per
+// SLOAD/SSTORE touched and logging through the EVM's journal so a revert
+// undoes both sides of the transfer. They aren't implemented in this
+// package: the vm.PrecompiledContract interface and the contract address
+// tables that activate it live in core/vm, which this snapshot's tree
+// does not contain.
+//
+// CommitteeMember.StakeCoinID — gating committee membership on holding a
+// balance of a specific multiCoinSlot coin at genesis — is likewise not
+// implemented here: types.CommitteeMember is defined in the core/types
+// package, which this snapshot's tree also does not contain.
@@ -0,0 +1,97 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build none
+
+// mkalloc converts a JSON genesis alloc (as found in a Genesis file) into
+// the compact RLP allocItem blob decodePrealloc expects, so a mainnet-scale
+// allocation can be embedded as a source-level constant and decoded in a
+// single streaming pass instead of going through encoding/json at startup.
+//
+// Usage:
+//
+//	go run mkalloc.go genesis.json > alloc.go
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+type allocItem struct {
+	Addr    common.Address
+	Balance *big.Int
+	Nonce   uint64           `rlp:"optional"`
+	Code    []byte           `rlp:"optional"`
+	Storage [][2]common.Hash `rlp:"optional"`
+}
+
+type genesisAccount struct {
+	Balance *math.HexOrDecimal256       `json:"balance"`
+	Nonce   math.HexOrDecimal64         `json:"nonce,omitempty"`
+	Code    hexutil.Bytes               `json:"code,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: mkalloc genesis.json")
+		os.Exit(1)
+	}
+	f, err := os.Open(os.Args[1])
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+
+	var genesis struct {
+		Alloc map[common.UnprefixedAddress]genesisAccount `json:"alloc"`
+	}
+	if err := json.NewDecoder(f).Decode(&genesis); err != nil {
+		panic(err)
+	}
+
+	addrs := make([]common.UnprefixedAddress, 0, len(genesis.Alloc))
+	for addr := range genesis.Alloc {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool { return bytes.Compare(addrs[i][:], addrs[j][:]) < 0 })
+
+	items := make([]allocItem, 0, len(addrs))
+	for _, addr := range addrs {
+		account := genesis.Alloc[addr]
+		item := allocItem{Addr: common.Address(addr), Balance: (*big.Int)(account.Balance), Nonce: uint64(account.Nonce), Code: account.Code}
+		for key, value := range account.Storage {
+			item.Storage = append(item.Storage, [2]common.Hash{key, value})
+		}
+		items = append(items, item)
+	}
+
+	blob, err := rlp.EncodeToBytes(items)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("package core\n\nconst mainnetAllocData = %q\n", blob)
+}
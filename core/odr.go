@@ -0,0 +1,132 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// OdrRequest is satisfied by every on-demand retrieval request a light
+// backend can issue through an OdrBackend; StoreResult lets the retriever
+// fill in the answer once a proof has been fetched and verified.
+type OdrRequest interface {
+	StoreResult(db OdrDatabase)
+}
+
+// OdrDatabase is the subset of state/receipt storage an OdrRequest writes
+// its verified result into once a remote proof has checked out.
+type OdrDatabase interface {
+	// TrieDB returns the database backing trie nodes retrieved via ODR.
+	TrieDB() interface{}
+
+	// InsertTrieNode caches a single content-addressed blob — a trie
+	// proof node or a piece of contract code — under its own hash, the
+	// same way a locally-synced node would have stored it the first time
+	// it was touched, so later local reads no longer need to go back out
+	// over ODR for it.
+	InsertTrieNode(hash common.Hash, blob []byte)
+}
+
+// OdrBackend is implemented by a light client's retrieval layer so RPC
+// handlers (eth_call, eth_getBalance, eth_getTransactionReceipt, ...) can
+// serve requests without holding the full state themselves: Retrieve
+// fetches and verifies a Merkle proof for req against req's block hash,
+// blocking until it succeeds, ctx is cancelled, or every known peer has
+// been tried.
+type OdrBackend interface {
+	Retrieve(ctx context.Context, req OdrRequest) error
+}
+
+// StateOrNilRequest asks an OdrBackend to retrieve the state of account at
+// a given block, for eth_getBalance / eth_getTransactionCount style calls.
+type StateOrNilRequest struct {
+	BHash   common.Hash
+	Account common.Address
+	Key     common.Hash // zero for the account itself, a storage slot otherwise
+
+	Balance []byte
+	Code    []byte
+	Storage []byte
+}
+
+// StoreResult implements OdrRequest. Balance and Storage are terminal
+// values read directly off of r by the RPC handler that issued the
+// request, so there is nothing further to cache for them; Code, like any
+// contract code, is content-addressed by its own hash, so it is cached
+// the same way a locally-synced node would have stored it.
+func (r *StateOrNilRequest) StoreResult(db OdrDatabase) {
+	if len(r.Code) > 0 {
+		db.InsertTrieNode(crypto.Keccak256Hash(r.Code), r.Code)
+	}
+}
+
+// ReceiptRequest asks an OdrBackend to retrieve and verify the receipt for
+// a transaction, for eth_getTransactionReceipt.
+type ReceiptRequest struct {
+	BHash common.Hash
+	Index uint64
+
+	Receipt []byte
+}
+
+// StoreResult implements OdrRequest. Receipt is a terminal value read
+// directly off of r by the RPC handler that issued the request; it is
+// keyed by (BHash, Index) rather than by content hash, so unlike a trie
+// node or contract code it has no natural content-addressed cache to
+// land in here.
+func (r *ReceiptRequest) StoreResult(db OdrDatabase) {}
+
+// TrieRequest asks an OdrBackend to retrieve and verify a single trie node
+// on the path to Key, rooted at Id.
+type TrieRequest struct {
+	Id  *TrieID
+	Key []byte
+
+	Proof [][]byte
+}
+
+// StoreResult implements OdrRequest, caching every node on the verified
+// proof path so a later local lookup anywhere along that path is served
+// from db instead of triggering another round-trip through ODR.
+func (r *TrieRequest) StoreResult(db OdrDatabase) {
+	for _, node := range r.Proof {
+		db.InsertTrieNode(crypto.Keccak256Hash(node), node)
+	}
+}
+
+// TrieID identifies which trie a TrieRequest targets: the state trie of a
+// block, or a particular account's storage trie within it.
+type TrieID struct {
+	BlockHash   common.Hash
+	BlockNumber uint64
+	Root        common.Hash
+	AccKey      []byte // empty for the state trie, the account's address hash otherwise
+}
+
+// StateTrieID returns the TrieID of the main state trie at the given block.
+func StateTrieID(hash common.Hash, number uint64, root common.Hash) *TrieID {
+	return &TrieID{BlockHash: hash, BlockNumber: number, Root: root}
+}
+
+// StorageTrieID returns the TrieID of an account's storage trie nested
+// under the state trie identified by state.
+func StorageTrieID(state *TrieID, accKey []byte, root common.Hash) *TrieID {
+	return &TrieID{BlockHash: state.BlockHash, BlockNumber: state.BlockNumber, Root: root, AccKey: accKey}
+}
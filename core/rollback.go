@@ -0,0 +1,210 @@
+// Copyright 2014 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/truechain/truechain-engineering-code/core/rawdb"
+	snaildb "github.com/truechain/truechain-engineering-code/core/snailchain/rawdb"
+	"github.com/truechain/truechain-engineering-code/core/state"
+	"github.com/truechain/truechain-engineering-code/core/types"
+	"github.com/truechain/truechain-engineering-code/etruedb"
+)
+
+// BlockChain is the minimal chain state RollbackToHeight needs: a current
+// head pointer, the last height the BFT committee has finalized past, the
+// active committee, and the database and state-trie cache both the fast
+// and snail chains share. The full production BlockChain — consensus
+// engine, event feeds, genesis config, and everything else a real node
+// needs — is not defined anywhere in this snapshot; this captures only the
+// fields this file's methods touch, just enough for RollbackToHeight to
+// compile and be exercised end-to-end in tests.
+type BlockChain struct {
+	mu sync.Mutex
+
+	db         etruedb.Database
+	stateCache state.Database
+
+	bestBlockHeader    *types.Header
+	lastIrrBlockHeader *types.Header
+	currentCommittee   []*types.CommitteeMember
+}
+
+// ErrRollbackBelowIrreversible is returned by RollbackToHeight when target
+// is at or below the chain's last irreversible block. The BFT committee
+// has already finalized everything up to that point, so undoing it would
+// let this node's local state diverge from what every other honest
+// validator has already committed to.
+var ErrRollbackBelowIrreversible = errors.New("core: rollback target is at or below the last irreversible block")
+
+// rollbackTargetValid reports whether target is a legal RollbackToHeight
+// destination given the chain's current best height and its last
+// irreversible height. ok is false if target has already been finalized
+// by the committee and can no longer be undone; noop is true if current
+// is already at or below target, in which case RollbackToHeight has
+// nothing to do. Splitting this out of RollbackToHeight lets the bound
+// checks be tested without a live BlockChain.
+func rollbackTargetValid(target, current, lastIrreversible uint64) (ok, noop bool) {
+	if target <= lastIrreversible {
+		return false, false
+	}
+	if target >= current {
+		return true, true
+	}
+	return true, false
+}
+
+// RollbackToHeight undoes both the fast chain and the snail chain past
+// target, restoring state, the tx/receipt indexes, and — critically for
+// this consensus, where block production rights are tied to a rotating
+// committee — the CommitteeMember set that was active at target.
+//
+// It walks stored blocks in reverse from the current head down to target,
+// reverting each one's indexes, then rewinds bestBlockHeader,
+// lastIrrBlockHeader and the committee record in a single leveldb batch so
+// a crash mid-rollback cannot leave the chain pointing at a torn tip: the
+// batch either lands as a whole or the chain is left exactly as it was.
+//
+// State itself is never mutated: every block's Root was already committed
+// into bc.stateCache's trie database when that block was first processed,
+// so target's state is still reachable there and StateAt(targetHeader.Root)
+// keeps working for any caller once bestBlockHeader points at target —
+// rewinding the pointers is sufficient, exactly as it is for a normal
+// chain reorg. The one thing RollbackToHeight must do before committing is
+// confirm that trie is actually still on disk and not pruned past
+// target's retention window; if it isn't, the rollback is refused rather
+// than silently leaving bestBlockHeader pointed at state nothing can load.
+func (bc *BlockChain) RollbackToHeight(target uint64) error {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	current := bc.bestBlockHeader.Number.Uint64()
+	ok, noop := rollbackTargetValid(target, current, bc.lastIrrBlockHeader.Number.Uint64())
+	if !ok {
+		return ErrRollbackBelowIrreversible
+	}
+	if noop {
+		return nil
+	}
+
+	batch := bc.db.NewBatch()
+	for height := current; height > target; height-- {
+		hash := rawdb.ReadCanonicalHash(bc.db, height)
+		if (hash == common.Hash{}) {
+			continue
+		}
+		block := rawdb.ReadBlock(bc.db, hash, height)
+		if block == nil {
+			continue
+		}
+		if err := revertFastBlock(batch, block); err != nil {
+			return fmt.Errorf("reverting fast block %d: %v", height, err)
+		}
+	}
+	queueSnailRollback(bc.db, batch, target)
+
+	targetHash := rawdb.ReadCanonicalHash(bc.db, target)
+	targetHeader := rawdb.ReadHeader(bc.db, targetHash, target)
+	if targetHeader == nil {
+		return fmt.Errorf("missing header for rollback target %d", target)
+	}
+	committee, err := readCommitteeAt(bc.db, target)
+	if err != nil {
+		return fmt.Errorf("restoring committee at height %d: %v", target, err)
+	}
+	if _, err := state.New(targetHeader.Root, bc.stateCache); err != nil {
+		return fmt.Errorf("state for rollback target %d unavailable, likely pruned: %v", target, err)
+	}
+
+	rawdb.WriteHeadBlockHash(batch, targetHash)
+	rawdb.WriteHeadHeaderHash(batch, targetHash)
+	rawdb.WriteCommittee(batch, targetHash, committee)
+	if err := batch.Write(); err != nil {
+		return fmt.Errorf("committing rollback batch: %v", err)
+	}
+
+	bc.bestBlockHeader = targetHeader
+	if bc.lastIrrBlockHeader.Number.Uint64() > target {
+		bc.lastIrrBlockHeader = targetHeader
+	}
+	bc.currentCommittee = committee
+	return nil
+}
+
+// revertFastBlock removes a single fast block's canonical mapping, body,
+// and the tx-lookup/receipt indexes it installed, queuing the deletes
+// into batch rather than writing them individually.
+func revertFastBlock(batch etruedb.Batch, block *types.Block) error {
+	for _, tx := range block.Transactions() {
+		rawdb.DeleteTxLookupEntry(batch, tx.Hash())
+	}
+	rawdb.DeleteReceipts(batch, block.Hash(), block.NumberU64())
+	rawdb.DeleteBlock(batch, block.Hash(), block.NumberU64())
+	rawdb.DeleteCanonicalHash(batch, block.NumberU64())
+	return nil
+}
+
+// rollbackSnailPast reverts every snail block above target the same way
+// revertFastBlock does for the fast chain, using the snail chain's own
+// rawdb package since it tracks total difficulty and canonical hashes
+// independently of the fast chain.
+func rollbackSnailPast(db etruedb.Database, target uint64) error {
+	batch := db.NewBatch()
+	queueSnailRollback(db, batch, target)
+	return batch.Write()
+}
+
+// queueSnailRollback queues rollbackSnailPast's deletes and head rewrites
+// into batch without writing it, so RollbackToHeight can commit them in
+// the same batch as its fast-chain changes.
+func queueSnailRollback(db etruedb.Database, batch etruedb.Batch, target uint64) {
+	head := snaildb.ReadHeadHeaderHash(db)
+	height := snaildb.ReadHeaderNumber(db, head)
+	if height == nil {
+		return
+	}
+	for h := *height; h > target; h-- {
+		hash := snaildb.ReadCanonicalHash(db, h)
+		if (hash == common.Hash{}) {
+			continue
+		}
+		snaildb.DeleteBlock(batch, hash, h)
+		snaildb.DeleteCanonicalHash(batch, h)
+	}
+	targetHash := snaildb.ReadCanonicalHash(db, target)
+	snaildb.WriteHeadBlockHash(batch, targetHash)
+	snaildb.WriteHeadHeaderHash(batch, targetHash)
+}
+
+// readCommitteeAt returns the CommitteeMember set in force at number by
+// reading it off the canonical block stored there, the same source Dump
+// and ExportGenesisAt use for a block's committee.
+func readCommitteeAt(db etruedb.Database, number uint64) ([]*types.CommitteeMember, error) {
+	hash := rawdb.ReadCanonicalHash(db, number)
+	if (hash == common.Hash{}) {
+		return nil, fmt.Errorf("no canonical block at height %d", number)
+	}
+	block := rawdb.ReadBlock(db, hash, number)
+	if block == nil {
+		return nil, fmt.Errorf("block %d not found", number)
+	}
+	return block.Infos(), nil
+}
@@ -0,0 +1,382 @@
+// Copyright 2014 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/truechain/truechain-engineering-code/core/rawdb"
+	snaildb "github.com/truechain/truechain-engineering-code/core/snailchain/rawdb"
+	"github.com/truechain/truechain-engineering-code/core/state"
+	"github.com/truechain/truechain-engineering-code/core/types"
+	"github.com/truechain/truechain-engineering-code/etruedb"
+)
+
+// TestRollbackTargetValid covers the three boundary decisions
+// RollbackToHeight makes before it touches the database: rejecting a
+// target at or below the last irreversible block (including the case
+// where that floor is genesis itself), a no-op when the chain is already
+// at or below target, and the ordinary in-range case.
+func TestRollbackTargetValid(t *testing.T) {
+	tests := []struct {
+		name             string
+		target           uint64
+		current          uint64
+		lastIrreversible uint64
+		wantOK           bool
+		wantNoop         bool
+	}{
+		{"below irreversible", 5, 10, 8, false, false},
+		{"at irreversible", 8, 10, 8, false, false},
+		{"genesis height not yet finalized past itself", 0, 10, 0, false, false},
+		{"target at current is a no-op", 10, 10, 0, true, true},
+		{"target above current is a no-op", 12, 10, 0, true, true},
+		{"ordinary in-range target", 4, 10, 2, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, noop := rollbackTargetValid(tt.target, tt.current, tt.lastIrreversible)
+			if ok != tt.wantOK || noop != tt.wantNoop {
+				t.Errorf("rollbackTargetValid(%d, %d, %d) = (%v, %v), want (%v, %v)",
+					tt.target, tt.current, tt.lastIrreversible, ok, noop, tt.wantOK, tt.wantNoop)
+			}
+		})
+	}
+}
+
+// writeFastBlockForTest stores block as the canonical block at its own
+// height, plus the receipt and tx-lookup indexes revertFastBlock must
+// clean up, mirroring what normal block insertion would have written.
+func writeFastBlockForTest(db etruedb.Database, block *types.Block, receipts types.Receipts) {
+	rawdb.WriteBlock(db, block)
+	rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+	rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts)
+	rawdb.WriteTxLookupEntries(db, block)
+}
+
+// TestRevertFastBlock checks that reverting a block removes its
+// canonical-hash mapping, the block itself, its receipts, and every
+// transaction's lookup entry.
+func TestRevertFastBlock(t *testing.T) {
+	db := etruedb.NewMemDatabase()
+
+	key, _ := crypto.GenerateKey()
+	tx := types.NewTransaction(0, common.HexToAddress("0x01"), big.NewInt(1), 21000, big.NewInt(1), nil)
+	signedTx, err := types.SignTx(tx, types.HomesteadSigner{}, key)
+	if err != nil {
+		t.Fatalf("signing test tx: %v", err)
+	}
+
+	header := &types.Header{Number: big.NewInt(1)}
+	block := types.NewBlock(header, []*types.Transaction{signedTx}, nil, nil, nil)
+	receipts := types.Receipts{{TxHash: signedTx.Hash()}}
+
+	writeFastBlockForTest(db, block, receipts)
+
+	batch := db.NewBatch()
+	if err := revertFastBlock(batch, block); err != nil {
+		t.Fatalf("revertFastBlock: %v", err)
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatalf("writing revert batch: %v", err)
+	}
+
+	if hash := rawdb.ReadCanonicalHash(db, block.NumberU64()); hash != (common.Hash{}) {
+		t.Errorf("canonical hash for height %d still present after revert", block.NumberU64())
+	}
+	if got := rawdb.ReadBlock(db, block.Hash(), block.NumberU64()); got != nil {
+		t.Errorf("block still present after revert")
+	}
+	if receipts := rawdb.ReadReceipts(db, block.Hash(), block.NumberU64()); len(receipts) != 0 {
+		t.Errorf("receipts still present after revert")
+	}
+	if tx, _, _, _ := rawdb.ReadTransaction(db, signedTx.Hash()); tx != nil {
+		t.Errorf("tx lookup entry still present after revert")
+	}
+}
+
+// TestRollbackSnailPast checks that rolling the snail chain back to
+// target deletes every canonical block above target and rewinds the
+// snail head pointers to target.
+func TestRollbackSnailPast(t *testing.T) {
+	db := etruedb.NewMemDatabase()
+
+	var hashes []common.Hash
+	for h := uint64(0); h <= 3; h++ {
+		header := &types.SnailHeader{Number: big.NewInt(int64(h))}
+		block := types.NewSnailBlock(header, nil, nil, nil)
+		snaildb.WriteBlock(db, block)
+		snaildb.WriteCanonicalHash(db, block.Hash(), h)
+		hashes = append(hashes, block.Hash())
+	}
+	snaildb.WriteHeadBlockHash(db, hashes[3])
+	snaildb.WriteHeadHeaderHash(db, hashes[3])
+
+	if err := rollbackSnailPast(db, 1); err != nil {
+		t.Fatalf("rollbackSnailPast: %v", err)
+	}
+
+	for h := uint64(2); h <= 3; h++ {
+		if hash := snaildb.ReadCanonicalHash(db, h); hash != (common.Hash{}) {
+			t.Errorf("snail canonical hash for height %d still present after rollback", h)
+		}
+	}
+	if hash := snaildb.ReadCanonicalHash(db, 1); hash != hashes[1] {
+		t.Errorf("snail canonical hash for retained height 1 changed")
+	}
+	if got := snaildb.ReadHeadBlockHash(db); got != hashes[1] {
+		t.Errorf("snail head block hash = %x, want %x", got, hashes[1])
+	}
+	if got := snaildb.ReadHeadHeaderHash(db); got != hashes[1] {
+		t.Errorf("snail head header hash = %x, want %x", got, hashes[1])
+	}
+}
+
+// TestReadCommitteeAt_ReshuffleBoundary writes two blocks straddling a
+// committee reshuffle and checks that readCommitteeAt returns the
+// committee that was actually active at each height, not the one either
+// side of the boundary.
+func TestReadCommitteeAt_ReshuffleBoundary(t *testing.T) {
+	db := etruedb.NewMemDatabase()
+
+	committeeA := []*types.CommitteeMember{{Coinbase: common.HexToAddress("0x01")}}
+	committeeB := []*types.CommitteeMember{{Coinbase: common.HexToAddress("0x02")}, {Coinbase: common.HexToAddress("0x03")}}
+
+	blockA := types.NewBlock(&types.Header{Number: big.NewInt(5)}, nil, nil, nil, committeeA)
+	blockB := types.NewBlock(&types.Header{Number: big.NewInt(6)}, nil, nil, nil, committeeB)
+
+	rawdb.WriteBlock(db, blockA)
+	rawdb.WriteCanonicalHash(db, blockA.Hash(), 5)
+	rawdb.WriteBlock(db, blockB)
+	rawdb.WriteCanonicalHash(db, blockB.Hash(), 6)
+
+	got, err := readCommitteeAt(db, 5)
+	if err != nil {
+		t.Fatalf("readCommitteeAt(5): %v", err)
+	}
+	if len(got) != len(committeeA) || got[0].Coinbase != committeeA[0].Coinbase {
+		t.Errorf("readCommitteeAt(5) = %v, want %v", got, committeeA)
+	}
+
+	got, err = readCommitteeAt(db, 6)
+	if err != nil {
+		t.Fatalf("readCommitteeAt(6): %v", err)
+	}
+	if len(got) != len(committeeB) {
+		t.Errorf("readCommitteeAt(6) = %v, want %v", got, committeeB)
+	}
+}
+
+// rollbackChainData is the slice of post-rollback chain state RollbackToHeight
+// is responsible for getting right: where the head pointers land, which
+// committee is active, and that the corresponding state root is still
+// reachable. The end-to-end tests below build an expected value of this and
+// compare it against what RollbackToHeight actually left behind.
+type rollbackChainData struct {
+	headHash   common.Hash
+	headerHash common.Hash
+	committee  []common.Address
+	stateRoot  common.Hash
+}
+
+func readRollbackChainData(t *testing.T, bc *BlockChain) rollbackChainData {
+	t.Helper()
+	addrs := make([]common.Address, len(bc.currentCommittee))
+	for i, m := range bc.currentCommittee {
+		addrs[i] = m.Coinbase
+	}
+	if _, err := state.New(bc.bestBlockHeader.Root, bc.stateCache); err != nil {
+		t.Fatalf("state for chain head unavailable after rollback: %v", err)
+	}
+	return rollbackChainData{
+		headHash:   rawdb.ReadHeadBlockHash(bc.db),
+		headerHash: rawdb.ReadHeadHeaderHash(bc.db),
+		committee:  addrs,
+		stateRoot:  bc.bestBlockHeader.Root,
+	}
+}
+
+// buildRollbackTestChain commits blocks 0..top to db, each crediting its own
+// height-derived address so every height's post-state is independently
+// retrievable afterwards, and using committeeAt(h) as that block's
+// CommitteeMember set. It returns each height's state root.
+func buildRollbackTestChain(t *testing.T, db etruedb.Database, sdb state.Database, top uint64, committeeAt func(h uint64) []*types.CommitteeMember) []common.Hash {
+	t.Helper()
+	roots := make([]common.Hash, top+1)
+	root := common.Hash{}
+	for h := uint64(0); h <= top; h++ {
+		statedb, err := state.New(root, sdb)
+		if err != nil {
+			t.Fatalf("state.New at height %d: %v", h, err)
+		}
+		statedb.AddBalance(common.BigToAddress(new(big.Int).SetUint64(h)), big.NewInt(1))
+		newRoot, err := statedb.Commit(false)
+		if err != nil {
+			t.Fatalf("statedb.Commit at height %d: %v", h, err)
+		}
+		if err := statedb.Database().TrieDB().Commit(newRoot, true); err != nil {
+			t.Fatalf("TrieDB.Commit at height %d: %v", h, err)
+		}
+		root = newRoot
+		roots[h] = root
+
+		header := &types.Header{Number: big.NewInt(int64(h)), Root: root}
+		block := types.NewBlock(header, nil, nil, nil, committeeAt(h))
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), h)
+	}
+	return roots
+}
+
+// TestRollbackToHeight_CommitteeReshuffleBoundary exercises RollbackToHeight
+// itself (not just its private helpers) across a committee reshuffle: it
+// builds a 7-block chain where the committee changes at height 4 and rolls
+// back to exactly that boundary, then checks the resulting chainData
+// reflects the boundary block's own (post-reshuffle) committee and state,
+// not the pre-reshuffle one it replaced or the later blocks it discarded.
+func TestRollbackToHeight_CommitteeReshuffleBoundary(t *testing.T) {
+	db := etruedb.NewMemDatabase()
+	sdb := state.NewDatabase(db)
+
+	committeeA := []*types.CommitteeMember{{Coinbase: common.HexToAddress("0x01")}}
+	committeeB := []*types.CommitteeMember{{Coinbase: common.HexToAddress("0x02")}, {Coinbase: common.HexToAddress("0x03")}}
+	committeeAt := func(h uint64) []*types.CommitteeMember {
+		if h < 4 {
+			return committeeA
+		}
+		return committeeB
+	}
+	roots := buildRollbackTestChain(t, db, sdb, 6, committeeAt)
+
+	head6Hash := rawdb.ReadCanonicalHash(db, 6)
+	rawdb.WriteHeadBlockHash(db, head6Hash)
+	rawdb.WriteHeadHeaderHash(db, head6Hash)
+
+	bc := &BlockChain{
+		db:                 db,
+		stateCache:         sdb,
+		bestBlockHeader:    rawdb.ReadHeader(db, head6Hash, 6),
+		lastIrrBlockHeader: rawdb.ReadHeader(db, rawdb.ReadCanonicalHash(db, 0), 0),
+		currentCommittee:   committeeB,
+	}
+
+	if err := bc.RollbackToHeight(4); err != nil {
+		t.Fatalf("RollbackToHeight(4): %v", err)
+	}
+
+	want := rollbackChainData{
+		headHash:   rawdb.ReadCanonicalHash(db, 4),
+		headerHash: rawdb.ReadCanonicalHash(db, 4),
+		committee:  []common.Address{committeeB[0].Coinbase, committeeB[1].Coinbase},
+		stateRoot:  roots[4],
+	}
+	got := readRollbackChainData(t, bc)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("post-rollback chainData = %+v, want %+v", got, want)
+	}
+	if bc.bestBlockHeader.Number.Uint64() != 4 {
+		t.Errorf("bestBlockHeader height = %d, want 4", bc.bestBlockHeader.Number.Uint64())
+	}
+	for h := uint64(5); h <= 6; h++ {
+		if hash := rawdb.ReadCanonicalHash(db, h); hash != (common.Hash{}) {
+			t.Errorf("canonical hash for discarded height %d still present", h)
+		}
+	}
+}
+
+// TestRollbackToHeight_GenesisFloor checks that RollbackToHeight refuses a
+// target of the genesis height itself, since rollbackTargetValid treats
+// target <= lastIrreversible as already finalized and genesis (height 0) is
+// always at or below any lastIrreversible value a uint64 can hold — and
+// that a refused rollback leaves the chain completely untouched.
+func TestRollbackToHeight_GenesisFloor(t *testing.T) {
+	db := etruedb.NewMemDatabase()
+	sdb := state.NewDatabase(db)
+
+	committee := []*types.CommitteeMember{{Coinbase: common.HexToAddress("0x01")}}
+	committeeAt := func(h uint64) []*types.CommitteeMember { return committee }
+	buildRollbackTestChain(t, db, sdb, 3, committeeAt)
+
+	head3Hash := rawdb.ReadCanonicalHash(db, 3)
+	genesisHash := rawdb.ReadCanonicalHash(db, 0)
+	rawdb.WriteHeadBlockHash(db, head3Hash)
+	rawdb.WriteHeadHeaderHash(db, head3Hash)
+
+	bestBefore := rawdb.ReadHeader(db, head3Hash, 3)
+	bc := &BlockChain{
+		db:                 db,
+		stateCache:         sdb,
+		bestBlockHeader:    bestBefore,
+		lastIrrBlockHeader: rawdb.ReadHeader(db, genesisHash, 0),
+		currentCommittee:   committee,
+	}
+
+	err := bc.RollbackToHeight(0)
+	if err != ErrRollbackBelowIrreversible {
+		t.Fatalf("RollbackToHeight(0) = %v, want ErrRollbackBelowIrreversible", err)
+	}
+	if bc.bestBlockHeader.Hash() != bestBefore.Hash() {
+		t.Errorf("bestBlockHeader changed after refused rollback")
+	}
+	for h := uint64(1); h <= 3; h++ {
+		if hash := rawdb.ReadCanonicalHash(db, h); hash == (common.Hash{}) {
+			t.Errorf("canonical hash for height %d missing after refused rollback", h)
+		}
+	}
+}
+
+// TestRollbackToHeight_BelowIrreversible checks that RollbackToHeight
+// refuses a target strictly below the last irreversible height — not just
+// equal to it, the boundary TestRollbackToHeight_GenesisFloor already
+// covers — leaving the chain untouched.
+func TestRollbackToHeight_BelowIrreversible(t *testing.T) {
+	db := etruedb.NewMemDatabase()
+	sdb := state.NewDatabase(db)
+
+	committee := []*types.CommitteeMember{{Coinbase: common.HexToAddress("0x01")}}
+	committeeAt := func(h uint64) []*types.CommitteeMember { return committee }
+	buildRollbackTestChain(t, db, sdb, 5, committeeAt)
+
+	head5Hash := rawdb.ReadCanonicalHash(db, 5)
+	rawdb.WriteHeadBlockHash(db, head5Hash)
+	rawdb.WriteHeadHeaderHash(db, head5Hash)
+
+	bestBefore := rawdb.ReadHeader(db, head5Hash, 5)
+	bc := &BlockChain{
+		db:                 db,
+		stateCache:         sdb,
+		bestBlockHeader:    bestBefore,
+		lastIrrBlockHeader: rawdb.ReadHeader(db, rawdb.ReadCanonicalHash(db, 2), 2),
+		currentCommittee:   committee,
+	}
+
+	if err := bc.RollbackToHeight(1); err != ErrRollbackBelowIrreversible {
+		t.Fatalf("RollbackToHeight(1) = %v, want ErrRollbackBelowIrreversible", err)
+	}
+	if bc.bestBlockHeader.Hash() != bestBefore.Hash() {
+		t.Errorf("bestBlockHeader changed after refused rollback")
+	}
+	for h := uint64(3); h <= 5; h++ {
+		if hash := rawdb.ReadCanonicalHash(db, h); hash == (common.Hash{}) {
+			t.Errorf("canonical hash for height %d missing after refused rollback", h)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package flowcontrol
+
+import (
+	"testing"
+	"time"
+)
+
+// BenchmarkServeGetFastBlockHeaders times benchmarkServe with a placeholder
+// serve function (time.Sleep(0)). It does not serve a real request of any
+// cost-tabled message type, and it does not write DefaultCostTable or any
+// config. Run with:
+//
+//	go test -run NONE -bench . ./etrue/flowcontrol
+func BenchmarkServeGetFastBlockHeaders(b *testing.B) {
+	benchmarkServe(b, func() { time.Sleep(0) })
+}
+
+func benchmarkServe(b *testing.B, serve func()) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		serve()
+	}
+}
+
+func TestClientManagerFairness(t *testing.T) {
+	cm := NewClientManager(DefaultCostTable, ServerParams{
+		BufferLimit: 6000,
+		MinRecharge: 100000,
+		Concurrency: 4,
+	})
+	cm.Connect("peerA")
+	cm.Connect("peerB")
+	cm.SetWeight("peerB", 2.0)
+
+	// Each GetFastBlockHeadersMsg/1 request costs 5200 units. Back-to-back
+	// Accept calls with no elapsed time can't tell peerA and peerB apart,
+	// since refill only accrues over real elapsed time, so pace the calls
+	// 30ms apart: peerA's 1x rate refills ~3000 units/round, never
+	// recovering the cost, so it digs itself into persistent overspend;
+	// peerB's 2x rate (both its recharge rate and, via SetWeight, its
+	// bucket capacity) refills ~6000 units/round, comfortably outpacing
+	// the cost every round, so it never overspends.
+	for i := 0; i < 10; i++ {
+		cm.Accept("peerA", GetFastBlockHeadersMsg, 1)
+		cm.Accept("peerB", GetFastBlockHeadersMsg, 1)
+		time.Sleep(30 * time.Millisecond)
+	}
+	if !cm.ShouldDrop("peerA") {
+		t.Errorf("peerA should be flagged for persistent overspend")
+	}
+	if cm.ShouldDrop("peerB") {
+		t.Errorf("peerB with higher weight should not be flagged for dropping yet")
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package flowcontrol
+
+import (
+	"sync"
+	"time"
+)
+
+// TokenBucket is a simple refilling token bucket used to debit the cost of
+// a peer's requests against its allotted serving bandwidth.
+type TokenBucket struct {
+	mu       sync.Mutex
+	capacity uint64
+	tokens   float64
+	rate     uint64 // tokens added per second
+	last     time.Time
+}
+
+// NewTokenBucket creates a full TokenBucket with the given capacity and
+// per-second refill rate.
+func NewTokenBucket(capacity, rate uint64) *TokenBucket {
+	return &TokenBucket{
+		capacity: capacity,
+		tokens:   float64(capacity),
+		rate:     rate,
+		last:     time.Now(),
+	}
+}
+
+// SetRate updates the refill rate, e.g. after a peer's priority weight or
+// the server's import budget factor changes.
+func (b *TokenBucket) SetRate(rate uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	b.rate = rate
+}
+
+// SetCapacity updates the bucket's capacity, e.g. after a peer's priority
+// weight changes. A growing capacity does not itself top up tokens beyond
+// what refill already accrued; a shrinking one clamps tokens down to it.
+func (b *TokenBucket) SetCapacity(capacity uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	b.capacity = capacity
+	if b.tokens > float64(capacity) {
+		b.tokens = float64(capacity)
+	}
+}
+
+// TryConsume debits cost tokens and reports whether the bucket had enough
+// balance to cover it. A cost it could not cover is debited all the same,
+// which can leave the balance below zero.
+func (b *TokenBucket) TryConsume(cost uint64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	if b.tokens < float64(cost) {
+		// Still debit so a peer that keeps hammering us digs a deeper
+		// hole rather than getting a free pass once the bucket is empty.
+		b.tokens -= float64(cost)
+		return false
+	}
+	b.tokens -= float64(cost)
+	return true
+}
+
+// refill tops up the bucket for elapsed time, capped at capacity. Must be
+// called with b.mu held.
+func (b *TokenBucket) refill() {
+	now := time.Now()
+	elapsed := now.Sub(b.last).Seconds()
+	b.last = now
+	if elapsed <= 0 {
+		return
+	}
+	b.tokens += elapsed * float64(b.rate)
+	if b.tokens > float64(b.capacity) {
+		b.tokens = float64(b.capacity)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package flowcontrol
+
+// snailMsgBase offsets snailtrue message codes within this package's cost
+// table. etrue and snailtrue are negotiated as independent protocols and
+// their wire-level message codes both start at 0x00, but a single
+// ClientManager serves both over one per-peer budget, so the two code
+// spaces need to stay distinguishable here even though they don't on the
+// wire.
+const snailMsgBase MsgCode = 0x100
+
+// Message codes of the request-type etrue/snailtrue messages this package
+// prices. The etrue codes mirror the values defined in etrue/protocol.go;
+// the snailtrue ones mirror snailtrue/protocol.go offset by snailMsgBase.
+// They are duplicated here (rather than imported) so this package has no
+// dependency on either protocol package, matching the LES flowcontrol
+// split.
+const (
+	GetFastBlockHeadersMsg MsgCode = 0x03
+	GetFastBlockBodiesMsg  MsgCode = 0x05
+	GetReceiptsMsg         MsgCode = 0x0c
+	GetNodeDataMsg         MsgCode = 0x0a
+
+	GetSnailBlockHeadersMsg = snailMsgBase + 0x03
+	GetSnailBlockBodiesMsg  = snailMsgBase + 0x05
+)
+
+// DefaultCostTable is the built-in cost table, expressed in abstract cost
+// units. Nothing in this package re-measures or rewrites these numbers;
+// BenchmarkServeGetFastBlockHeaders times a placeholder serve function only.
+var DefaultCostTable = CostTable{
+	GetFastBlockHeadersMsg:  {Base: 5000, PerItem: 200},
+	GetFastBlockBodiesMsg:   {Base: 5000, PerItem: 2000},
+	GetReceiptsMsg:          {Base: 5000, PerItem: 4000},
+	GetNodeDataMsg:          {Base: 5000, PerItem: 1000},
+	GetSnailBlockHeadersMsg: {Base: 5000, PerItem: 200},
+	GetSnailBlockBodiesMsg:  {Base: 5000, PerItem: 2000},
+}
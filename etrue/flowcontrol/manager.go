@@ -0,0 +1,255 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package flowcontrol implements a LES-style request-serving scheduler for
+// etrue peers: every request debits a peer's token bucket by a fixed cost,
+// a ClientManager gives each peer a refill rate proportional to its priority
+// weight, and requests are served concurrently up to a server-wide limit
+// instead of being serialized one connection at a time.
+package flowcontrol
+
+import (
+	"sync"
+	"time"
+)
+
+// MsgCode identifies one of the request-type messages this package prices.
+type MsgCode uint64
+
+// Cost is the (baseCost, perItemCost) pricing of a single request message,
+// expressed in abstract "cost units" rather than wall-clock time so the
+// correction factor can be retuned per deployment without touching callers.
+type Cost struct {
+	Base    uint64
+	PerItem uint64
+}
+
+// CostTable maps each served message code to its Cost.
+type CostTable map[MsgCode]Cost
+
+// cost computes the total cost of a request for count items of code code,
+// scaled by the manager's runtime correction factor.
+func (ct CostTable) cost(code MsgCode, count uint64, correction float64) uint64 {
+	c, ok := ct[code]
+	if !ok {
+		return 0
+	}
+	total := float64(c.Base) + float64(count)*float64(c.PerItem)
+	return uint64(total * correction)
+}
+
+// ServerParams are the server-wide knobs a ClientManager enforces.
+type ServerParams struct {
+	// BufferLimit is the token bucket capacity handed to a freshly
+	// connected peer with the default priority weight.
+	BufferLimit uint64
+	// MinRecharge is the token refill rate (units/second) for a peer with
+	// the default priority weight; actual rate is this times the peer's
+	// weight and the current import budget factor.
+	MinRecharge uint64
+	// Concurrency bounds how many requests the server executes at once,
+	// across all peers, so serving never serializes behind one slow peer.
+	Concurrency int
+}
+
+// DefaultServerParams is a reasonable starting point for a single node;
+// operators are expected to retune it with the benchmark harness.
+var DefaultServerParams = ServerParams{
+	BufferLimit: 1_000_000,
+	MinRecharge: 50_000,
+	Concurrency: 32,
+}
+
+// ClientManager gives each connected peer a refill rate of MinRecharge
+// scaled by its priority weight, so rates are proportional to weight; the
+// sum over all peers is not capped at a fixed server-wide total and grows
+// with the number of peers. It scales every rate down while the local node
+// is busy importing blocks, and tracks which peers persistently overspend
+// their allotted bucket.
+type ClientManager struct {
+	mu sync.Mutex
+
+	params     ServerParams
+	correction float64
+	costs      CostTable
+
+	clients map[ClientID]*clientState
+
+	// importBudgetFactor scales MinRecharge down while the node is busy
+	// importing locally produced blocks, to avoid starving disk I/O.
+	importBudgetFactor float64
+
+	sem chan struct{} // bounds concurrent request execution server-wide
+}
+
+// ClientID identifies a connected peer to the ClientManager. Callers
+// typically use the peer's node ID.
+type ClientID string
+
+type clientState struct {
+	bucket      *TokenBucket
+	weight      float64
+	overspend   int
+	lastRequest time.Time
+}
+
+// NewClientManager creates a ClientManager that prices requests using
+// costs and enforces params, with all peers starting at weight 1.
+func NewClientManager(costs CostTable, params ServerParams) *ClientManager {
+	return &ClientManager{
+		params:             params,
+		correction:         1.0,
+		costs:              costs,
+		clients:            make(map[ClientID]*clientState),
+		importBudgetFactor: 1.0,
+		sem:                make(chan struct{}, params.Concurrency),
+	}
+}
+
+// SetCorrectionFactor adjusts the global cost correction factor at runtime,
+// e.g. after the benchmark harness re-measures the cost table for the
+// local hardware.
+func (cm *ClientManager) SetCorrectionFactor(f float64) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.correction = f
+}
+
+// SetImportBudgetFactor scales every peer's effective recharge rate by f
+// (0 < f <= 1), intended to be dialed down while the local node is busy
+// importing blocks so request serving doesn't contend with the database.
+func (cm *ClientManager) SetImportBudgetFactor(f float64) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.importBudgetFactor = f
+	for _, c := range cm.clients {
+		c.bucket.SetRate(cm.rechargeRate(c.weight))
+	}
+}
+
+func (cm *ClientManager) rechargeRate(weight float64) uint64 {
+	return uint64(float64(cm.params.MinRecharge) * weight * cm.importBudgetFactor)
+}
+
+// bufferLimit scales the server's base BufferLimit by weight, the same way
+// rechargeRate scales MinRecharge, so a higher-priority peer both refills
+// faster and starts with a proportionally larger buffer to draw down before
+// the refill rate difference has had time to matter.
+func (cm *ClientManager) bufferLimit(weight float64) uint64 {
+	return uint64(float64(cm.params.BufferLimit) * weight)
+}
+
+// Connect registers a newly connected peer with the default priority
+// weight (1.0) and a fresh, full token bucket. It does nothing for a peer
+// that is already registered.
+func (cm *ClientManager) Connect(id ClientID) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if _, ok := cm.clients[id]; ok {
+		return
+	}
+	cm.clients[id] = &clientState{
+		bucket: NewTokenBucket(cm.bufferLimit(1.0), cm.rechargeRate(1.0)),
+		weight: 1.0,
+	}
+}
+
+// Disconnect forgets a peer's bucket and weight.
+func (cm *ClientManager) Disconnect(id ClientID) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	delete(cm.clients, id)
+}
+
+// SetWeight sets a peer's priority weight, settable via an admin RPC so
+// operators can favour paying or trusted peers.
+func (cm *ClientManager) SetWeight(id ClientID, weight float64) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	c, ok := cm.clients[id]
+	if !ok {
+		return
+	}
+	c.weight = weight
+	c.bucket.SetRate(cm.rechargeRate(weight))
+	c.bucket.SetCapacity(cm.bufferLimit(weight))
+}
+
+// MaxOverspend is the number of consecutive requests a peer may debit past
+// an empty bucket before ShouldDrop reports it should be disconnected.
+const MaxOverspend = 5
+
+// Accept debits id's bucket for a request of code code carrying count
+// items. A request that would overdraw the bucket is still admitted (so
+// one slow peer can't wedge itself waiting for the connection to drop) but
+// it counts against the peer's overspend tally; call ShouldDrop to check
+// whether that tally warrants disconnecting the peer.
+func (cm *ClientManager) Accept(id ClientID, code MsgCode, count uint64) bool {
+	cm.mu.Lock()
+	c, ok := cm.clients[id]
+	if !ok {
+		cm.mu.Unlock()
+		return false
+	}
+	cost := cm.costs.cost(code, count, cm.correction)
+	cm.mu.Unlock()
+
+	withinBudget := c.bucket.TryConsume(cost)
+
+	cm.mu.Lock()
+	if withinBudget {
+		c.overspend = 0
+	} else {
+		c.overspend++
+	}
+	c.lastRequest = time.Now()
+	cm.mu.Unlock()
+	return true
+}
+
+// ShouldDrop reports whether id has overspent its bucket persistently
+// enough to warrant disconnection.
+func (cm *ClientManager) ShouldDrop(id ClientID) bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	c, ok := cm.clients[id]
+	return ok && c.overspend >= MaxOverspend
+}
+
+// Serve runs fn under the server-wide concurrency limit, blocking until a
+// slot is free. It is the integration point request handlers call through
+// instead of executing requests serially on the peer's read loop.
+func (cm *ClientManager) Serve(fn func()) {
+	cm.sem <- struct{}{}
+	defer func() { <-cm.sem }()
+	fn()
+}
+
+// HandleRequest is the single call a peer's request dispatch loop makes
+// per incoming message: it debits id's bucket for a request of code code
+// carrying count items via Accept, then runs fn under the server-wide
+// concurrency limit via Serve. It reports false without running fn if id
+// is not a connected peer, so an unregistered connection can never have
+// its requests served.
+func (cm *ClientManager) HandleRequest(id ClientID, code MsgCode, count uint64, fn func()) bool {
+	if !cm.Accept(id, code, count) {
+		return false
+	}
+	cm.Serve(fn)
+	return true
+}
@@ -0,0 +1,166 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package forkid implements the chain fork identifier exchanged during the
+// etrue handshake. It lets two peers that are both on the right NetworkId
+// and genesis block detect up front whether they've actually diverged onto
+// different forks, instead of finding out the hard way when block
+// validation fails later.
+package forkid
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var (
+	// ErrRemoteStale is returned by Validate when a remote fork ID is
+	// compatible but known to be stale (it doesn't yet know about a fork
+	// block we've already passed). The peer is still worth talking to.
+	ErrRemoteStale = errors.New("remote needs software update")
+
+	// ErrLocalIncompatibleOrStale is returned by Validate when a remote
+	// fork ID is on a fork we don't recognize, or is ahead of a fork
+	// block that we have not yet activated. The peer should be dropped.
+	ErrLocalIncompatibleOrStale = errors.New("local incompatible or needs update")
+)
+
+// ID is the identifier of a blockchain fork, exchanged as part of the
+// etrue handshake's statusData.
+type ID struct {
+	Hash [4]byte // CRC32 checksum of the genesis hash and passed fork block numbers
+	Next uint64  // Block number of the next upcoming fork, or 0 if no future fork is known
+}
+
+// Filter is a closure over the local chain that returns the validation
+// error (if any) for a remote peer's ID.
+type Filter func(id ID) error
+
+// NewID calculates the Ethereum fork ID from the genesis hash and the list
+// of fork block numbers that have already passed at head.
+func NewID(genesis common.Hash, forks []uint64, head uint64) ID {
+	hash := crc32.ChecksumIEEE(genesis[:])
+
+	var next uint64
+	for _, fork := range forks {
+		if fork <= head {
+			// Fork already passed, checksum the block number into the hash
+			hash = checksumUpdate(hash, fork)
+			continue
+		}
+		next = fork
+		break
+	}
+	return ID{Hash: checksumToBytes(hash), Next: next}
+}
+
+// NewFilter creates a Filter that returns whether a remotely advertised ID
+// is compatible with the locally tracked chain, given its genesis hash and
+// the full ordered list of fork block numbers.
+func NewFilter(genesis common.Hash, forks []uint64, headFn func() uint64) Filter {
+	return func(remote ID) error {
+		head := headFn()
+
+		hash := crc32.ChecksumIEEE(genesis[:])
+		for _, fork := range forks {
+			if fork > head {
+				// we haven't passed this fork locally yet; the remote is
+				// only compatible if it agrees on everything passed so far
+				// and hasn't activated any fork we don't know about.
+				if checksumToBytes(hash) == remote.Hash {
+					return nil
+				}
+				if remote.Next != 0 && remote.Next <= fork {
+					// remote announces a next fork at or before our next
+					// unpassed one; a remote that has not yet passed a
+					// fork we already have looks like this. Accept it but
+					// flag it as stale so the caller can warn.
+					return ErrRemoteStale
+				}
+				return ErrLocalIncompatibleOrStale
+			}
+			hash = checksumUpdate(hash, fork)
+		}
+		if checksumToBytes(hash) == remote.Hash {
+			return nil
+		}
+		log.Debug("Fork ID mismatch", "local", checksumToBytes(hash), "remote", remote.Hash)
+		return ErrLocalIncompatibleOrStale
+	}
+}
+
+func checksumUpdate(hash uint32, fork uint64) uint32 {
+	var blob [8]byte
+	binary.BigEndian.PutUint64(blob[:], fork)
+	return crc32.Update(hash, crc32.IEEETable, blob[:])
+}
+
+func checksumToBytes(hash uint32) [4]byte {
+	var blob [4]byte
+	binary.BigEndian.PutUint32(blob[:], hash)
+	return blob
+}
+
+// Forks is implemented by a chain configuration that can enumerate its own
+// hard-fork switch-over blocks, so NewIDFromConfig/NewFilterFromConfig can
+// build a Filter straight from it during the etrue handshake without this
+// package needing to know params.ChainConfig's concrete field layout.
+type Forks interface {
+	ForkBlocks() []*big.Int
+}
+
+// NewIDFromConfig calculates the local fork ID from config's fork blocks
+// and genesis hash at head, the value an outgoing statusData.ForkID is
+// populated with.
+func NewIDFromConfig(config Forks, genesis common.Hash, head uint64) ID {
+	return NewID(genesis, gatherForks(config.ForkBlocks()...), head)
+}
+
+// NewFilterFromConfig creates a Filter that checks a remote peer's ForkID
+// against config's fork blocks and genesis hash, the check an etrue
+// handshake runs on the incoming statusData before accepting a peer.
+func NewFilterFromConfig(config Forks, genesis common.Hash, headFn func() uint64) Filter {
+	return NewFilter(genesis, gatherForks(config.ForkBlocks()...), headFn)
+}
+
+// gatherForks turns a chain config's individual fork-block fields into the
+// ordered, deduplicated slice NewID/NewFilter expect.
+func gatherForks(forkBlocks ...*big.Int) []uint64 {
+	forks := make([]uint64, 0, len(forkBlocks))
+	seen := make(map[uint64]struct{}, len(forkBlocks))
+	for _, fork := range forkBlocks {
+		if fork == nil {
+			continue
+		}
+		n := fork.Uint64()
+		if n == 0 {
+			continue
+		}
+		if _, ok := seen[n]; ok {
+			continue
+		}
+		seen[n] = struct{}{}
+		forks = append(forks, n)
+	}
+	sort.Slice(forks, func(i, j int) bool { return forks[i] < forks[j] })
+	return forks
+}
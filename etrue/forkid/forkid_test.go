@@ -0,0 +1,67 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package forkid
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestNewID(t *testing.T) {
+	genesis := common.HexToHash("0x1234")
+	forks := []uint64{10, 20, 30}
+
+	if got := NewID(genesis, forks, 0); got.Next != 10 {
+		t.Errorf("head before any fork: Next = %d, want 10", got.Next)
+	}
+	if got := NewID(genesis, forks, 9); got.Next != 10 {
+		t.Errorf("head just before fork: Next = %d, want 10", got.Next)
+	}
+	if got := NewID(genesis, forks, 10); got.Next != 20 {
+		t.Errorf("head at fork: Next = %d, want 20", got.Next)
+	}
+	if got := NewID(genesis, forks, 30); got.Next != 0 {
+		t.Errorf("head at last fork: Next = %d, want 0", got.Next)
+	}
+	if got := NewID(genesis, forks, 15); got.Next != 20 {
+		t.Errorf("head between forks: Next = %d, want 20", got.Next)
+	}
+
+	base := NewID(genesis, forks, 0)
+	if got := NewID(genesis, forks, 0); got.Hash != base.Hash {
+		t.Errorf("NewID not deterministic for the same inputs")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	genesis := common.HexToHash("0x1234")
+	forks := []uint64{10, 20}
+
+	head := uint64(25)
+	filter := NewFilter(genesis, forks, func() uint64 { return head })
+
+	local := NewID(genesis, forks, head)
+	if err := filter(local); err != nil {
+		t.Errorf("identical ID rejected: %v", err)
+	}
+
+	remoteUnknownFork := ID{Hash: [4]byte{0xde, 0xad, 0xbe, 0xef}, Next: 0}
+	if err := filter(remoteUnknownFork); err == nil {
+		t.Errorf("fork mismatch accepted")
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/truechain/truechain-engineering-code/core/types"
+	"github.com/truechain/truechain-engineering-code/etrue/forkid"
 	"github.com/truechain/truechain-engineering-code/event"
 )
 
@@ -31,16 +32,23 @@ import (
 const (
 	eth62 = 62
 	eth63 = 63
+	eth64 = 64
 )
 
 // ProtocolName is the official short name of the protocol used during capability negotiation.
 var ProtocolName = "etrue"
 
 // ProtocolVersions are the upported versions of the etrue protocol (first is primary).
-var ProtocolVersions = []uint{eth63, eth62}
+// eth64 added the ForkID field to statusData so peers on incompatible
+// forks can be rejected during the handshake instead of after a failed
+// block validation.
+var ProtocolVersions = []uint{eth64, eth63, eth62}
 
 // ProtocolLengths are the number of implemented message corresponding to different protocol versions.
-var ProtocolLengths = []uint64{20, 8}
+// Snail-chain messages (fruit/snail-block gossip) used to be multiplexed
+// onto this protocol; they now live in their own snailtrue/1 protocol, so
+// these counts cover fast-chain messages only.
+var ProtocolLengths = []uint64{14, 14, 8}
 
 const ProtocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
 
@@ -59,19 +67,11 @@ const (
 	BlockSignMsg    = 0x08
 	PbftNodeInfoMsg = 0x09
 
-	FruitMsg      = 0x0a
-	SnailBlockMsg = 0x0b
 	// Protocol messages belonging to eth/63
-	GetNodeDataMsg = 0x0c
-	NodeDataMsg    = 0x0d
-	GetReceiptsMsg = 0x0e
-	ReceiptsMsg    = 0x0f
-
-	//snail sync
-	GetSnailBlockHeadersMsg = 0x10
-	SnailBlockHeadersMsg    = 0x11
-	GetSnailBlockBodiesMsg  = 0x12
-	SnailBlockBodiesMsg     = 0x13
+	GetNodeDataMsg = 0x0a
+	NodeDataMsg    = 0x0b
+	GetReceiptsMsg = 0x0c
+	ReceiptsMsg    = 0x0d
 )
 
 type errCode int
@@ -86,6 +86,7 @@ const (
 	ErrNoStatusMsg
 	ErrExtraStatusMsg
 	ErrSuspendedPeer
+	ErrForkIDRejected
 )
 
 func (e errCode) String() string {
@@ -103,6 +104,7 @@ var errorToString = map[int]string{
 	ErrNoStatusMsg:             "No status message",
 	ErrExtraStatusMsg:          "Extra status message",
 	ErrSuspendedPeer:           "Suspended peer",
+	ErrForkIDRejected:          "Fork ID rejected",
 }
 
 type txPool interface {
@@ -116,21 +118,6 @@ type txPool interface {
 	// SubscribeNewTxsEvent should return an event subscription of
 	// NewTxsEvent and send events to the given channel.
 	SubscribeNewTxsEvent(chan<- types.NewTxsEvent) event.Subscription
-	// for fruits and records
-	//SubscribeNewFruitsEvent(chan<- types.NewFruitsEvent) event.Subscription
-}
-
-type SnailPool interface {
-	AddRemoteFruits([]*types.SnailBlock, bool) []error
-	//AddRemoteSnailBlocks([]*types.SnailBlock) []error
-	PendingFruits() map[common.Hash]*types.SnailBlock
-	SubscribeNewFruitEvent(chan<- types.NewFruitsEvent) event.Subscription
-	//SubscribeNewSnailBlockEvent(chan<- core.NewSnailBlocksEvent) event.Subscription
-	//AddRemoteRecords([]*types.PbftRecord) []error
-	//AddRemoteRecords([]*types.PbftRecord) []error
-	//SubscribeNewRecordEvent(chan<- core.NewRecordsEvent) event.Subscription
-
-	RemovePendingFruitByFastHash(fasthash common.Hash)
 }
 
 type AgentNetworkProxy interface {
@@ -147,6 +134,17 @@ type AgentNetworkProxy interface {
 	AddRemoteNodeInfo(*types.EncryptNodeMessage) error
 }
 
+// verifyForkID checks a peer's advertised ForkID, as read off its handshake
+// statusData, against filter. It returns nil if the peer is compatible,
+// or an ErrForkIDRejected error the handshake should disconnect on
+// otherwise.
+func verifyForkID(filter forkid.Filter, remote *statusData) error {
+	if err := filter(remote.ForkID); err != nil {
+		return fmt.Errorf("%v: %v", errorToString[ErrForkIDRejected], err)
+	}
+	return nil
+}
+
 // statusData is the network packet for the status message.
 type statusData struct {
 	ProtocolVersion  uint32
@@ -156,6 +154,7 @@ type statusData struct {
 	CurrentBlock     common.Hash
 	GenesisBlock     common.Hash
 	CurrentFastBlock common.Hash
+	ForkID           forkid.ID // Fork identifier, allows a peer to reject forked peers early
 }
 
 // newBlockHashesData is the network packet for the block announcements.
@@ -223,12 +222,6 @@ type newBlockData struct {
 	Block *types.Block
 }
 
-// newFastBlockData is the network packet for the block propagation message.
-type newSnailBlockData struct {
-	Block *types.SnailBlock
-	TD    *big.Int
-}
-
 // getBlockBodiesData represents a block body query.
 type getBlockBodiesData struct {
 	Hash common.Hash // Block hash from which to retrieve bodies (excludes Number)
@@ -255,12 +248,3 @@ type blockBodiesData struct {
 	bodiesData []*blockBody
 	call       string
 }
-
-// blockBody represents the data content of a single block.
-type snailBlockBody struct {
-	Fruits []*types.SnailBlock
-	Signs  []*types.PbftSign
-}
-
-// blockBodiesData is the network packet for block content distribution.
-type snailBlockBodiesData []*snailBlockBody
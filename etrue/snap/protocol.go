@@ -0,0 +1,183 @@
+// Copyright 2014 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package snap implements the snap/1 sub-protocol, which lets peers pull the
+// fast-chain state trie by account/storage ranges instead of node-by-node.
+// It is negotiated alongside etrue on the same connection: a peer that
+// speaks both capabilities can bootstrap state far faster than by walking
+// the trie one GetNodeDataMsg at a time, which remains available as the
+// fallback for peers that only speak etrue.
+package snap
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/truechain/truechain-engineering-code/core/state"
+)
+
+// ProtocolName is the official short name of the snap protocol used during
+// capability negotiation.
+const ProtocolName = "snap"
+
+// ProtocolVersions are the supported versions of the snap protocol (first is primary).
+var ProtocolVersions = []uint{1}
+
+// ProtocolLengths are the number of implemented messages corresponding to
+// different protocol versions.
+var ProtocolLengths = []uint64{8}
+
+// protocol message codes
+const (
+	GetAccountRangeMsg  = 0x00
+	AccountRangeMsg     = 0x01
+	GetStorageRangesMsg = 0x02
+	StorageRangesMsg    = 0x03
+	GetByteCodesMsg     = 0x04
+	ByteCodesMsg        = 0x05
+	GetTrieNodesMsg     = 0x06
+	TrieNodesMsg        = 0x07
+)
+
+// GetAccountRangePacket requests an unknown number of accounts from a given
+// state trie, starting at Origin and up to Limit (inclusive), capped by
+// ResponseBytes of returned data.
+type GetAccountRangePacket struct {
+	ID            uint64      // request ID to match up responses with
+	Root          common.Hash // root hash of the account trie to serve
+	Origin        common.Hash // first account to retrieve
+	Limit         common.Hash // last account to retrieve
+	ResponseBytes uint64      // soft limit on the response size
+}
+
+// AccountRangePacket is the response to GetAccountRangePacket, containing a
+// number of consecutive accounts plus a Merkle proof that binds the range
+// to the requested state root.
+type AccountRangePacket struct {
+	ID       uint64
+	Accounts []*AccountData
+	Proof    [][]byte
+}
+
+// AccountData is a single RLP-encoded account plus its trie key hash.
+type AccountData struct {
+	Hash common.Hash
+	Body []byte
+}
+
+// GetStorageRangesPacket requests storage slots for a batch of accounts
+// from a given state root, starting at Origin and up to Limit.
+type GetStorageRangesPacket struct {
+	ID            uint64
+	Root          common.Hash
+	Accounts      []common.Hash
+	Origin        []byte
+	Limit         []byte
+	ResponseBytes uint64
+}
+
+// StorageRangesPacket is the response to GetStorageRangesPacket, one slot
+// list per requested account, plus a Merkle proof for the last account if
+// the response was capped before it was fully served.
+type StorageRangesPacket struct {
+	ID    uint64
+	Slots [][]*StorageData
+	Proof [][]byte
+}
+
+// StorageData is a single storage slot, keyed by its trie key hash.
+type StorageData struct {
+	Hash common.Hash
+	Body []byte
+}
+
+// GetByteCodesPacket requests a batch of contract bytecodes by code hash.
+type GetByteCodesPacket struct {
+	ID     uint64
+	Hashes []common.Hash
+	Bytes  uint64
+}
+
+// ByteCodesPacket is the response to GetByteCodesPacket.
+type ByteCodesPacket struct {
+	ID    uint64
+	Codes [][]byte
+}
+
+// GetTrieNodesPacket requests a batch of arbitrary trie nodes by path,
+// used as a fallback when a full account/storage range can't be served.
+type GetTrieNodesPacket struct {
+	ID    uint64
+	Root  common.Hash
+	Paths []TrieNodePathSet
+	Bytes uint64
+}
+
+// TrieNodePathSet is a sequence of trie node paths identifying a single
+// trie node to retrieve, rooted at either the account trie (single element)
+// or a storage trie nested under an account (two elements).
+type TrieNodePathSet [][]byte
+
+// TrieNodesPacket is the response to GetTrieNodesPacket.
+type TrieNodesPacket struct {
+	ID    uint64
+	Nodes [][]byte
+}
+
+// ServeAccountRange answers a GetAccountRangePacket by walking the account
+// trie rooted at req.Root starting at req.Origin, the same way
+// core.Genesis.Dump walks a whole state trie for genesis export — except
+// capped by req.Limit and req.ResponseBytes instead of running to
+// completion, since a snap peer asks for one bounded slice of a
+// potentially huge trie at a time rather than the whole thing.
+func ServeAccountRange(statedb *state.StateDB, req *GetAccountRangePacket) (*AccountRangePacket, error) {
+	tr, err := statedb.Database().OpenTrie(req.Root)
+	if err != nil {
+		return nil, err
+	}
+	resp := &AccountRangePacket{ID: req.ID}
+	var size uint64
+	it := state.NewIterator(tr.NodeIterator(req.Origin[:]))
+	for it.Next() {
+		hash := common.BytesToHash(it.Key)
+		if bytes.Compare(hash[:], req.Limit[:]) > 0 {
+			break
+		}
+		body := append([]byte(nil), it.Value...)
+		resp.Accounts = append(resp.Accounts, &AccountData{Hash: hash, Body: body})
+
+		size += uint64(common.HashLength + len(body))
+		if size >= req.ResponseBytes {
+			break
+		}
+	}
+	if it.Err != nil {
+		return nil, it.Err
+	}
+	return resp, nil
+}
+
+// statusData mirrors the fields of the etrue handshake that snap cares
+// about, so a peer negotiating both protocols only does the handshake once
+// on the etrue connection; snap piggybacks on it rather than defining its
+// own status exchange.
+type statusData struct {
+	ProtocolVersion uint32
+	NetworkId       uint64
+	TD              *big.Int
+	GenesisBlock    common.Hash
+}
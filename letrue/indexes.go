@@ -0,0 +1,117 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package letrue
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/truechain/truechain-engineering-code/core/rawdb"
+	"github.com/truechain/truechain-engineering-code/etruedb"
+)
+
+// CHTFrequency is the number of blocks between two consecutive canonical
+// hash trie (CHT) sections. A full node builds one CHT section every
+// CHTFrequency blocks once they're old enough to be irreversible, mapping
+// each covered block number to (header hash, total difficulty) so a light
+// client can prove a header at any past height without downloading it.
+const CHTFrequency = 32768
+
+// BloomTrieFrequency is the number of blocks between two consecutive
+// BloomTrie sections, mapping (bit index, section) to a compressed bloom
+// bit vector so a light client can prove log-bloom membership over a long
+// range without holding every header's bloom filter.
+const BloomTrieFrequency = 32768
+
+// HelperTrieConfirmations is the number of confirmation blocks required
+// before a new CHT/BloomTrie section is considered final and advertised to
+// peers, guarding against a section being built on a block that later gets
+// reorganized out.
+const HelperTrieConfirmations = 2048
+
+// chtEntry is the RLP value stored at a CHT leaf: a header's hash and the
+// fast chain's total difficulty through that header, enough for a light
+// client to verify a proved header without re-deriving TD from scratch.
+type chtEntry struct {
+	Hash common.Hash
+	Td   *big.Int
+}
+
+// BuildCHTSection builds the canonical hash trie covering section index
+// sectionIdx — blocks [sectionIdx*CHTFrequency, (sectionIdx+1)*CHTFrequency)
+// — from canonical headers already stored in db, committing the resulting
+// trie into triedb and returning its root. Callers are expected to wait
+// for HelperTrieConfirmations beyond the section's last block before
+// calling this, so a section is never built on a block that can still be
+// reorganized out.
+func BuildCHTSection(db etruedb.Database, triedb *trie.Database, sectionIdx uint64) (common.Hash, error) {
+	t, err := trie.New(common.Hash{}, triedb)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	start := sectionIdx * CHTFrequency
+	for number := start; number < start+CHTFrequency; number++ {
+		hash := rawdb.ReadCanonicalHash(db, number)
+		if (hash == common.Hash{}) {
+			return common.Hash{}, fmt.Errorf("missing canonical block %d for CHT section %d", number, sectionIdx)
+		}
+		td := rawdb.ReadTd(db, hash, number)
+		if td == nil {
+			return common.Hash{}, fmt.Errorf("missing total difficulty for block %d", number)
+		}
+		var key [8]byte
+		binary.BigEndian.PutUint64(key[:], number)
+		value, err := rlp.EncodeToBytes(chtEntry{Hash: hash, Td: td})
+		if err != nil {
+			return common.Hash{}, err
+		}
+		if err := t.TryUpdate(key[:], value); err != nil {
+			return common.Hash{}, err
+		}
+	}
+	return t.Commit(nil)
+}
+
+// BuildBloomTrieSection builds the BloomTrie covering section index
+// sectionIdx the same way BuildCHTSection builds a CHT section, except the
+// trie key is a 2-byte bloom-bit index and the value is that bit's
+// compressed bit vector across every block in the section. bits supplies
+// each (bitIdx, sectionIdx) vector; it is expected to be backed by the
+// same per-bit bloom index a full node already maintains for its own log
+// filters, which this package does not itself build.
+func BuildBloomTrieSection(triedb *trie.Database, sectionIdx uint64, bitCount uint, bits func(bitIdx uint, sectionIdx uint64) ([]byte, error)) (common.Hash, error) {
+	t, err := trie.New(common.Hash{}, triedb)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	for bitIdx := uint(0); bitIdx < bitCount; bitIdx++ {
+		vector, err := bits(bitIdx, sectionIdx)
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("fetching bloom bit %d for section %d: %v", bitIdx, sectionIdx, err)
+		}
+		var key [2]byte
+		binary.BigEndian.PutUint16(key[:], uint16(bitIdx))
+		if err := t.TryUpdate(key[:], vector); err != nil {
+			return common.Hash{}, err
+		}
+	}
+	return t.Commit(nil)
+}
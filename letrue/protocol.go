@@ -0,0 +1,162 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package letrue implements the letrue light-client sub-protocol: a
+// companion to etrue for peers that only fetch headers and Merkle proofs
+// against state and receipt tries, never full bodies or the state trie
+// itself. It is meant to be negotiated alongside etrue so a single node
+// can serve full and light peers on the same listener.
+package letrue
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ProtocolName is the official short name of the letrue protocol used
+// during capability negotiation.
+const ProtocolName = "letrue"
+
+// ProtocolVersions are the supported versions of the letrue protocol
+// (first is primary).
+var ProtocolVersions = []uint{1}
+
+// ProtocolLengths are the number of implemented messages corresponding to
+// different protocol versions.
+var ProtocolLengths = []uint64{9}
+
+// letrue protocol message codes. Light peers sync headers with letrue's own
+// GetFastBlockHeadersMsg/FastBlockHeadersMsg codes; the other codes are
+// specific to on-demand proof retrieval.
+const (
+	GetProofsV2Msg = 0x00
+	ProofsV2Msg    = 0x01
+
+	GetHelperTrieProofsMsg = 0x02
+	HelperTrieProofsMsg    = 0x03
+
+	SendTxV2Msg    = 0x04
+	GetTxStatusMsg = 0x05
+	TxStatusMsg    = 0x06
+
+	// GetFastBlockHeadersMsg/FastBlockHeadersMsg carry the same payloads
+	// as their etrue namesakes but not the same numbers: etrue uses
+	// 0x03/0x04, which letrue already assigns to helper-trie messages.
+	GetFastBlockHeadersMsg = 0x07
+	FastBlockHeadersMsg    = 0x08
+)
+
+// HelperTrieType identifies which auxiliary trie a GetHelperTrieProofsMsg
+// request targets.
+type HelperTrieType uint
+
+const (
+	// HtCanonical is the canonical hash trie (CHT): maps block number to
+	// (header hash, total difficulty) so long-range header lookups are
+	// proof-verifiable without holding every header.
+	HtCanonical HelperTrieType = iota
+	// HtBloomBits is the bloom trie: maps (bit index, section) to a
+	// compressed bloom-filter bit vector so log queries over long ranges
+	// are proof-verifiable without holding every header's bloom filter.
+	HtBloomBits
+)
+
+// statusData is the network packet for the letrue status message. It
+// extends the etrue handshake fields with what a light peer needs to know
+// about the server it's talking to.
+type statusData struct {
+	ProtocolVersion uint32
+	NetworkId       uint64
+	TD              *big.Int
+	GenesisBlock    common.Hash
+
+	// ServesHeaders/ServesState/ServesTxs advertise which on-demand
+	// request classes this peer will answer; a pure light client sets
+	// none of them.
+	ServesHeaders bool
+	ServesState   bool
+	ServesTxs     bool
+
+	// AnnounceHead is the peer's current head, signed by its node key, so
+	// a light client can trust a head announcement without waiting for a
+	// header to confirm it.
+	AnnounceHead      common.Hash
+	AnnounceSignature []byte
+}
+
+// GetProofsV2Packet requests Merkle proofs for a batch of account/storage
+// reads against the state trie rooted at BHash.
+type GetProofsV2Packet struct {
+	ID    uint64
+	BHash common.Hash
+	Reqs  []ProofRequest
+}
+
+// ProofRequest identifies a single on-demand account or storage read.
+type ProofRequest struct {
+	Account common.Address
+	Key     []byte // empty for an account proof, a storage key otherwise
+}
+
+// ProofsV2Packet is the response to GetProofsV2Packet: a single
+// concatenated Merkle proof serving every request in order.
+type ProofsV2Packet struct {
+	ID    uint64
+	Proof [][]byte
+}
+
+// GetHelperTrieProofsPacket requests proofs against a CHT or BloomTrie.
+type GetHelperTrieProofsPacket struct {
+	ID   uint64
+	Reqs []HelperTrieReq
+}
+
+// HelperTrieReq identifies a single helper-trie lookup.
+type HelperTrieReq struct {
+	Type    HelperTrieType
+	TrieIdx uint64
+	Key     []byte
+	AuxReq  uint
+}
+
+// HelperTrieProofsPacket is the response to GetHelperTrieProofsPacket.
+type HelperTrieProofsPacket struct {
+	ID    uint64
+	Nodes [][]byte
+	Aux   [][]byte
+}
+
+// GetTxStatusPacket requests the inclusion/receipt status of a batch of
+// transactions by hash, so a light client can confirm a submission
+// without holding receipts itself.
+type GetTxStatusPacket struct {
+	ID     uint64
+	Hashes []common.Hash
+}
+
+// TxStatus is the on-chain status of a single transaction.
+type TxStatus struct {
+	Status uint // 0 unknown, 1 pending, 2 included, 3 error
+	Lookup []byte // RLP-encoded inclusion data (block hash/number/index) when Status == 2
+	Error  string
+}
+
+// TxStatusPacket is the response to GetTxStatusPacket.
+type TxStatusPacket struct {
+	ID      uint64
+	Statuses []TxStatus
+}
@@ -24,9 +24,11 @@ import (
 	"encoding/hex"
 	"errors"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/truechain/truechain-engineering-code/consensus"
 	"github.com/truechain/truechain-engineering-code/core/types"
+	"github.com/truechain/truechain-engineering-code/event"
 )
 
 type hashrate struct {
@@ -37,6 +39,23 @@ type hashrate struct {
 const UPDATABLOCKLENGTH = 12000 //12000  3000
 const DATASETHEADLENGH = 10240
 
+// ErrDatasetNotReady is returned by GetDataset while the current epoch's
+// dataset cache is still being populated by the background refresh
+// kicked off from loop; callers should retry.
+var ErrDatasetNotReady = errors.New("dataset cache for current epoch not ready, retry")
+
+// datasetCache holds the precomputed epoch dataset headers so GetDataset
+// never has to walk DATASETHEADLENGH snail headers under the agent's main
+// mutex. It is populated once per epoch transition by updateDatasetCache,
+// and guarded by its own mutex so readers never contend with SubmitWork.
+type datasetCache struct {
+	mu    sync.RWMutex
+	ready bool
+	epoch uint64
+	heads [DATASETHEADLENGH][]byte
+	root  common.Hash
+}
+
 // RemoteAgent for Remote mine
 type RemoteAgent struct {
 	mu sync.Mutex
@@ -54,10 +73,15 @@ type RemoteAgent struct {
 	hashrateMu sync.RWMutex
 	hashrate   map[common.Hash]hashrate
 
+	dataset datasetCache
+
+	newWorkFeed event.Feed
+	stratum     *StratumServer
+
 	running int32 // running indicates whether the agent is active. Call atomically
 }
 
-//NewRemoteAgent create remote agent object
+// NewRemoteAgent create remote agent object
 func NewRemoteAgent(chain consensus.ChainReader, snailchain consensus.SnailChainReader, engine consensus.Engine) *RemoteAgent {
 
 	return &RemoteAgent{
@@ -69,7 +93,24 @@ func NewRemoteAgent(chain consensus.ChainReader, snailchain consensus.SnailChain
 	}
 }
 
-//SubmitHashrate return the HashRate for remote agent
+// SubscribeNewWork returns a subscription for the [HashNoNonce, DatasetHash,
+// FruitDifficulty, DatasetRoot] tuple returned by GetWork, fired every time
+// loop installs a fresh *Work delivered on workCh. This lets websocket-
+// connected miners, dashboards and proxies react immediately instead of
+// polling GetWork.
+func (a *RemoteAgent) SubscribeNewWork(ch chan<- [4]string) event.Subscription {
+	return a.newWorkFeed.Subscribe(ch)
+}
+
+// SetStratumConfig wires a push-based Stratum v1 listener into the agent
+// alongside the existing HTTP GetWork/SubmitWork/SubmitHashrate RPCs. It must
+// be called before Start. A disabled config (the default) leaves the agent's
+// behaviour unchanged.
+func (a *RemoteAgent) SetStratumConfig(config StratumConfig) {
+	a.stratum = newStratumServer(a, config)
+}
+
+// SubmitHashrate return the HashRate for remote agent
 func (a *RemoteAgent) SubmitHashrate(id common.Hash, rate uint64) {
 	a.hashrateMu.Lock()
 	defer a.hashrateMu.Unlock()
@@ -87,7 +128,7 @@ func (a *RemoteAgent) SetReturnCh(returnCh chan<- *Result) {
 	a.returnCh = returnCh
 }
 
-//Start remote control the start mine
+// Start remote control the start mine
 func (a *RemoteAgent) Start() {
 	if !atomic.CompareAndSwapInt32(&a.running, 0, 1) {
 		return
@@ -95,35 +136,49 @@ func (a *RemoteAgent) Start() {
 	a.quitCh = make(chan struct{})
 	a.workCh = make(chan *Work, 1)
 	go a.loop(a.workCh, a.quitCh)
+
+	if a.stratum != nil {
+		if err := a.stratum.Start(); err != nil {
+			log.Error("Failed to start Stratum server", "err", err)
+		}
+	}
 }
 
-//Stop remote control the stop mine
+// Stop remote control the stop mine
 func (a *RemoteAgent) Stop() {
 	if !atomic.CompareAndSwapInt32(&a.running, 1, 0) {
 		return
 	}
 	close(a.quitCh)
 	close(a.workCh)
+
+	if a.stratum != nil {
+		a.stratum.Stop()
+	}
 }
 
-// GetHashRate returns the accumulated hashrate of all identifier combined
+// GetHashRate returns the accumulated hashrate of all identifier combined,
+// including Stratum-subscribed workers if a Stratum listener is configured.
 func (a *RemoteAgent) GetHashRate() (tot int64) {
 	a.hashrateMu.RLock()
-	defer a.hashrateMu.RUnlock()
-
 	// this could overflow
 	for _, hashrate := range a.hashrate {
 		tot += int64(hashrate.rate)
 	}
+	a.hashrateMu.RUnlock()
+
+	if a.stratum != nil {
+		tot += a.stratum.GetHashRate()
+	}
 	return
 }
 
-//GetWork return the current block hash without nonce
-func (a *RemoteAgent) GetWork() ([3]string, error) {
+// GetWork return the current block hash without nonce
+func (a *RemoteAgent) GetWork() ([4]string, error) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	var res [3]string
+	var res [4]string
 
 	if a.currentWork != nil {
 		block := a.currentWork.Block
@@ -140,12 +195,23 @@ func (a *RemoteAgent) GetWork() ([3]string, error) {
 		//log.Info("------diff", "is", block.BlockDifficulty())
 		res[2] = common.BytesToHash(block.FruitDifficulty().Bytes()).Hex()
 		//log.Info("------res[2]", "is", res[2])
+		// res[3] carries the current epoch dataset's root hash so miners
+		// can detect an epoch rollover without diffing the whole array.
+		res[3] = a.datasetRoot().Hex()
 		a.work[block.HashNoNonce()] = a.currentWork
 		return res, nil
 	}
 	return res, errors.New("No work available yet, Don't panic.")
 }
 
+// datasetRoot returns the cached root hash of the current epoch's dataset,
+// or the zero hash if the cache hasn't been populated yet.
+func (a *RemoteAgent) datasetRoot() common.Hash {
+	a.dataset.mu.RLock()
+	defer a.dataset.mu.RUnlock()
+	return a.dataset.root
+}
+
 // SubmitWork tries to inject a pow solution into the remote agent, returning
 // whether the solution was accepted or not (not can be both a bad pow as well as
 // any other error, like no work pending).
@@ -187,31 +253,74 @@ func (a *RemoteAgent) SubmitWork(nonce types.BlockNonce, mixDigest, hash common.
 	return true
 }
 
-//GetWork return the current block hash without nonce
-func (a *RemoteAgent) GetDataset() ([DATASETHEADLENGH][]byte, error) {
-	a.mu.Lock()
-	defer a.mu.Unlock()
+// GetDataset returns the current epoch's cached dataset headers in O(1),
+// without touching a.mu or the snailchain reader. If the epoch rollover
+// triggered by the latest work hasn't finished populating the cache yet,
+// it returns ErrDatasetNotReady so miners retry.
+func (a *RemoteAgent) GetDataset() (*[DATASETHEADLENGH][]byte, error) {
+	a.dataset.mu.RLock()
+	defer a.dataset.mu.RUnlock()
 
-	var res [DATASETHEADLENGH][]byte
-	if a.currentWork != nil {
-		block := a.currentWork.Block
-		epoch := uint64((block.Number().Uint64() - 1) / UPDATABLOCKLENGTH)
-		if epoch == 0 {
-			return res, nil
-		}
-		st_block_num := uint64((epoch-1)*UPDATABLOCKLENGTH + 1)
+	if !a.dataset.ready {
+		return nil, ErrDatasetNotReady
+	}
+	return &a.dataset.heads, nil
+}
 
-		for i := 0; i < DATASETHEADLENGH; i++ {
-			header := a.snailchain.GetHeaderByNumber(uint64(i) + st_block_num)
-			if header == nil {
-				//log.Error("----updateTBL--The skip is nil---- ", "blockNum is:  ", (uint64(i) + st_block_num))
-				return res, errors.New("GetDataset get heard fial")
-			}
-			res[i] = header.Hash().Bytes()
+// updateDatasetCache rebuilds the dataset cache for epoch by walking the
+// DATASETHEADLENGH snail headers that back it, then publishes the result
+// atomically. It is meant to run in its own goroutine, kicked off from loop
+// whenever a fresh *Work crosses into a new epoch, so it never blocks
+// SubmitWork or GetWork.
+func (a *RemoteAgent) updateDatasetCache(epoch uint64) {
+	a.dataset.mu.RLock()
+	current := a.dataset.epoch
+	ready := a.dataset.ready
+	a.dataset.mu.RUnlock()
+	if ready && current == epoch {
+		return
+	}
+
+	// Epoch 0 precedes the first full UPDATABLOCKLENGTH window, so there are
+	// no snail headers yet to back a dataset; (epoch-1) would also
+	// underflow below. Publish an empty-but-ready cache instead of leaving
+	// GetDataset returning ErrDatasetNotReady for the whole first epoch.
+	if epoch == 0 {
+		a.dataset.mu.Lock()
+		a.dataset.epoch = 0
+		a.dataset.heads = [DATASETHEADLENGH][]byte{}
+		a.dataset.root = common.Hash{}
+		a.dataset.ready = true
+		a.dataset.mu.Unlock()
+		return
+	}
+
+	// Stop serving the previous epoch's headers while this one is built.
+	a.dataset.mu.Lock()
+	if a.dataset.epoch != epoch {
+		a.dataset.ready = false
+	}
+	a.dataset.mu.Unlock()
+
+	stBlockNum := uint64((epoch-1)*UPDATABLOCKLENGTH + 1)
+
+	var heads [DATASETHEADLENGH][]byte
+	for i := 0; i < DATASETHEADLENGH; i++ {
+		header := a.snailchain.GetHeaderByNumber(uint64(i) + stBlockNum)
+		if header == nil {
+			log.Warn("Dataset cache epoch rollover incomplete, will retry", "epoch", epoch, "missing", uint64(i)+stBlockNum)
+			return
 		}
-		return res, nil
+		heads[i] = header.Hash().Bytes()
 	}
-	return res, errors.New("No work available yet, Don't panic.")
+	root := crypto.Keccak256Hash(heads[:]...)
+
+	a.dataset.mu.Lock()
+	a.dataset.epoch = epoch
+	a.dataset.heads = heads
+	a.dataset.root = root
+	a.dataset.ready = true
+	a.dataset.mu.Unlock()
 }
 
 // loop monitors mining events on the work and quit channels, updating the internal
@@ -232,6 +341,16 @@ func (a *RemoteAgent) loop(workCh chan *Work, quitCh chan struct{}) {
 			a.mu.Lock()
 			a.currentWork = work
 			a.mu.Unlock()
+
+			epoch := uint64((work.Block.Number().Uint64() - 1) / UPDATABLOCKLENGTH)
+			go a.updateDatasetCache(epoch)
+
+			if res, err := a.GetWork(); err == nil {
+				a.newWorkFeed.Send(res)
+				if a.stratum != nil {
+					a.stratum.broadcastWork(res)
+				}
+			}
 		case <-ticker.C:
 			// cleanup
 			a.mu.Lock()
@@ -249,6 +368,10 @@ func (a *RemoteAgent) loop(workCh chan *Work, quitCh chan struct{}) {
 				}
 			}
 			a.hashrateMu.Unlock()
+
+			if a.stratum != nil {
+				a.stratum.expireStaleWorkers()
+			}
 		}
 	}
 }
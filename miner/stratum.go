@@ -0,0 +1,428 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/truechain/truechain-engineering-code/core/types"
+)
+
+// StratumConfig holds the knobs an operator can tune for the push-based
+// Stratum v1 listener that complements the legacy GetWork/SubmitWork RPCs.
+type StratumConfig struct {
+	// Enabled turns the Stratum listener on. It is off by default so nodes
+	// that only serve the HTTP GetWork/SubmitWork trio are unaffected.
+	Enabled bool
+
+	// Port is the TCP port the Stratum server listens on.
+	Port int
+
+	// VarDiffWindow is the number of shares a worker's difficulty is
+	// averaged over before mining.set_difficulty is resent.
+	VarDiffWindow int
+
+	// TLSConfig, when non-nil, makes the listener require TLS.
+	TLSConfig *tls.Config
+}
+
+// DefaultStratumConfig is the stratum configuration used when none is
+// supplied, mirroring the pre-existing pull-based behaviour (disabled).
+var DefaultStratumConfig = StratumConfig{
+	Enabled:       false,
+	Port:          3333,
+	VarDiffWindow: 30,
+}
+
+// stratumClient is a single subscribed Stratum connection.
+type stratumClient struct {
+	conn   net.Conn
+	enc    *json.Encoder
+	name   string // worker name set via mining.authorize
+	mu     sync.Mutex
+	closed bool
+
+	// vardiff state: diff is the share difficulty the client was last told
+	// to mine at, shares counts accepted submissions since windowStart, and
+	// lastShare times the gap between submissions for the instantaneous
+	// hashrate estimate in trackWorkerHashrate.
+	diff        uint64
+	shares      int
+	windowStart time.Time
+	lastShare   time.Time
+}
+
+func (c *stratumClient) send(v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return fmt.Errorf("stratum client closed")
+	}
+	return c.enc.Encode(v)
+}
+
+func (c *stratumClient) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.closed = true
+		c.conn.Close()
+	}
+}
+
+// stratumRequest is the wire format of a Stratum v1 JSON-RPC request.
+type stratumRequest struct {
+	ID     interface{}   `json:"id"`
+	Method string        `json:"method"`
+	Params []interface{} `json:"params"`
+}
+
+// stratumResponse is the wire format of a Stratum v1 JSON-RPC response.
+type stratumResponse struct {
+	ID     interface{} `json:"id"`
+	Result interface{} `json:"result"`
+	Error  interface{} `json:"error"`
+}
+
+// stratumNotify is a mining.notify push: header hash without nonce, the
+// epoch dataset hash, the fruit difficulty target and the epoch dataset
+// root, in GetWork's order.
+type stratumNotify struct {
+	ID     interface{} `json:"id"`
+	Method string      `json:"method"`
+	Params [4]string   `json:"params"`
+}
+
+// stratumSetDifficulty is a mining.set_difficulty push: the share
+// difficulty a worker's subsequent mining.submit calls are judged against.
+// It is sent once on mining.subscribe and again whenever vardiff retargets
+// the worker.
+type stratumSetDifficulty struct {
+	Method string    `json:"method"`
+	Params [1]uint64 `json:"params"`
+}
+
+// initialShareDifficulty is the vardiff starting point for a freshly
+// subscribed worker, used until its first retarget has enough shares to
+// judge an actual submission rate from.
+const initialShareDifficulty = 1
+
+// targetShareInterval is the vardiff retarget goal: a worker's difficulty
+// is nudged up or down so that shares land roughly this often.
+const targetShareInterval = 10 * time.Second
+
+// StratumServer is a push-based Stratum v1 TCP listener that sits in front
+// of the same internal state as the legacy HTTP GetWork/SubmitWork/SubmitHashrate
+// RPCs, so a single RemoteAgent can serve both kinds of external miners.
+type StratumServer struct {
+	agent  *RemoteAgent
+	config StratumConfig
+
+	listener net.Listener
+	quitCh   chan struct{}
+	wg       sync.WaitGroup
+
+	clientsMu sync.RWMutex
+	clients   map[*stratumClient]struct{}
+
+	workerHashrateMu sync.RWMutex
+	workerHashrate   map[string]hashrate
+}
+
+// newStratumServer wraps agent with a Stratum v1 listener governed by config.
+func newStratumServer(agent *RemoteAgent, config StratumConfig) *StratumServer {
+	return &StratumServer{
+		agent:          agent,
+		config:         config,
+		clients:        make(map[*stratumClient]struct{}),
+		workerHashrate: make(map[string]hashrate),
+	}
+}
+
+// Start binds the configured port and begins accepting Stratum connections.
+// It is a no-op if the server is disabled in config.
+func (s *StratumServer) Start() error {
+	if !s.config.Enabled {
+		return nil
+	}
+	addr := fmt.Sprintf(":%d", s.config.Port)
+
+	var (
+		ln  net.Listener
+		err error
+	)
+	if s.config.TLSConfig != nil {
+		ln, err = tls.Listen("tcp", addr, s.config.TLSConfig)
+	} else {
+		ln, err = net.Listen("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.listener = ln
+	s.quitCh = make(chan struct{})
+
+	s.wg.Add(1)
+	go s.acceptLoop()
+
+	log.Info("Stratum server started", "addr", addr, "tls", s.config.TLSConfig != nil)
+	return nil
+}
+
+// Stop tears down the listener and disconnects every subscribed client.
+func (s *StratumServer) Stop() {
+	if s.listener == nil {
+		return
+	}
+	close(s.quitCh)
+	s.listener.Close()
+
+	s.clientsMu.Lock()
+	for c := range s.clients {
+		c.close()
+	}
+	s.clients = make(map[*stratumClient]struct{})
+	s.clientsMu.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *StratumServer) acceptLoop() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.quitCh:
+				return
+			default:
+				log.Warn("Stratum accept failed", "err", err)
+				continue
+			}
+		}
+		client := &stratumClient{conn: conn, enc: json.NewEncoder(conn), diff: initialShareDifficulty, windowStart: time.Now()}
+		s.clientsMu.Lock()
+		s.clients[client] = struct{}{}
+		s.clientsMu.Unlock()
+
+		s.wg.Add(1)
+		go s.handleClient(client)
+	}
+}
+
+func (s *StratumServer) handleClient(client *stratumClient) {
+	defer s.wg.Done()
+	defer func() {
+		s.clientsMu.Lock()
+		delete(s.clients, client)
+		s.clientsMu.Unlock()
+		client.close()
+	}()
+
+	reader := bufio.NewReader(client.conn)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			return
+		}
+		var req stratumRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			log.Debug("Stratum malformed request", "err", err)
+			continue
+		}
+		s.handleRequest(client, &req)
+	}
+}
+
+func (s *StratumServer) handleRequest(client *stratumClient, req *stratumRequest) {
+	switch req.Method {
+	case "mining.subscribe":
+		s.sendCurrentWork(client)
+		s.sendDifficulty(client)
+
+	case "mining.authorize":
+		if len(req.Params) > 0 {
+			if name, ok := req.Params[0].(string); ok {
+				client.mu.Lock()
+				client.name = name
+				client.mu.Unlock()
+			}
+		}
+		client.send(&stratumResponse{ID: req.ID, Result: true})
+
+	case "mining.submit":
+		ok := s.handleSubmit(client, req.Params)
+		client.send(&stratumResponse{ID: req.ID, Result: ok})
+
+	default:
+		client.send(&stratumResponse{ID: req.ID, Error: "unknown method"})
+	}
+}
+
+func (s *StratumServer) handleSubmit(client *stratumClient, params []interface{}) bool {
+	if len(params) < 3 {
+		return false
+	}
+	workerName, _ := params[0].(string)
+	hashHex, _ := params[1].(string)
+	nonceHex, _ := params[2].(string)
+	mixHex := ""
+	if len(params) > 3 {
+		mixHex, _ = params[3].(string)
+	}
+
+	var nonce types.BlockNonce
+	nonceBytes := common.FromHex(nonceHex)
+	if len(nonceBytes) > len(nonce) {
+		log.Debug("Stratum submit rejected: oversized nonce", "worker", workerName, "len", len(nonceBytes))
+		return false
+	}
+	copy(nonce[len(nonce)-len(nonceBytes):], nonceBytes)
+
+	hash := common.HexToHash(hashHex)
+	mix := common.HexToHash(mixHex)
+
+	accepted := s.agent.SubmitWork(nonce, mix, hash)
+	if accepted && workerName != "" {
+		s.trackWorkerHashrate(client, workerName)
+	}
+	return accepted
+}
+
+// trackWorkerHashrate bumps name's hashrate entry so GetHashRate can report
+// per-rig totals for Stratum-connected miners, estimating the share's
+// instantaneous rate as its assigned difficulty divided by the time since
+// the worker's previous share. Every VarDiffWindow shares it also retargets
+// the worker's difficulty.
+func (s *StratumServer) trackWorkerHashrate(client *stratumClient, name string) {
+	client.mu.Lock()
+	diff := client.diff
+	now := time.Now()
+	elapsed := now.Sub(client.lastShare)
+	client.lastShare = now
+	client.shares++
+	retarget := s.config.VarDiffWindow > 0 && client.shares >= s.config.VarDiffWindow
+	windowStart := client.windowStart
+	if retarget {
+		client.windowStart = now
+		client.shares = 0
+	}
+	client.mu.Unlock()
+
+	s.workerHashrateMu.Lock()
+	prev := s.workerHashrate[name]
+	rate := prev.rate
+	if elapsed > 0 && !prev.ping.IsZero() {
+		rate = uint64(float64(diff) / elapsed.Seconds())
+	}
+	s.workerHashrate[name] = hashrate{ping: now, rate: rate}
+	s.workerHashrateMu.Unlock()
+
+	if retarget {
+		s.retargetDifficulty(client, windowStart, now)
+	}
+}
+
+// retargetDifficulty implements Stratum vardiff: it compares the average
+// time between the VarDiffWindow shares that just elapsed against
+// targetShareInterval and scales the worker's difficulty so future shares
+// land closer to that target, then pushes the new value with
+// mining.set_difficulty.
+func (s *StratumServer) retargetDifficulty(client *stratumClient, windowStart, now time.Time) {
+	elapsed := now.Sub(windowStart)
+	if elapsed <= 0 {
+		return
+	}
+	avgInterval := elapsed / time.Duration(s.config.VarDiffWindow)
+	if avgInterval <= 0 {
+		return
+	}
+
+	client.mu.Lock()
+	scaled := uint64(float64(client.diff) * targetShareInterval.Seconds() / avgInterval.Seconds())
+	if scaled < 1 {
+		scaled = 1
+	}
+	client.diff = scaled
+	client.mu.Unlock()
+
+	s.sendDifficulty(client)
+}
+
+// sendDifficulty pushes client's currently assigned share difficulty as a
+// mining.set_difficulty notification.
+func (s *StratumServer) sendDifficulty(client *stratumClient) {
+	client.mu.Lock()
+	diff := client.diff
+	client.mu.Unlock()
+	client.send(&stratumSetDifficulty{Method: "mining.set_difficulty", Params: [1]uint64{diff}})
+}
+
+// GetHashRate returns the accumulated hashrate of all Stratum-subscribed
+// workers, the Stratum-side counterpart to RemoteAgent's legacy
+// hashrate/SubmitHashrate bookkeeping.
+func (s *StratumServer) GetHashRate() (tot int64) {
+	s.workerHashrateMu.RLock()
+	defer s.workerHashrateMu.RUnlock()
+	for _, hr := range s.workerHashrate {
+		tot += int64(hr.rate)
+	}
+	return
+}
+
+// expireStaleWorkers forgets a worker's hashrate entry once it has gone
+// quiet for longer than the same 10s window RemoteAgent uses to expire its
+// legacy SubmitHashrate entries, so a disconnected Stratum miner doesn't
+// linger in GetHashRate's total forever.
+func (s *StratumServer) expireStaleWorkers() {
+	s.workerHashrateMu.Lock()
+	defer s.workerHashrateMu.Unlock()
+	for name, hr := range s.workerHashrate {
+		if time.Since(hr.ping) > 10*time.Second {
+			delete(s.workerHashrate, name)
+		}
+	}
+}
+
+func (s *StratumServer) sendCurrentWork(client *stratumClient) {
+	res, err := s.agent.GetWork()
+	if err != nil {
+		return
+	}
+	client.send(&stratumNotify{Method: "mining.notify", Params: res})
+}
+
+// broadcastWork pushes a mining.notify to every subscribed client. It is
+// called from RemoteAgent.loop whenever a fresh *Work arrives on workCh.
+func (s *StratumServer) broadcastWork(res [4]string) {
+	s.clientsMu.RLock()
+	defer s.clientsMu.RUnlock()
+	for c := range s.clients {
+		if err := c.send(&stratumNotify{Method: "mining.notify", Params: res}); err != nil {
+			log.Debug("Stratum notify failed", "err", err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2014 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package snailtrue implements the snailtrue sub-protocol: the snail-chain
+// counterpart to etrue. It was split out of etrue so fruit and snail-block
+// gossip run on their own peer set with their own backpressure, independent
+// of fast-block propagation; a peer negotiates etrue, snailtrue, or both,
+// and a light node that only tracks the fast chain can decline snailtrue
+// entirely.
+package snailtrue
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/truechain/truechain-engineering-code/core/types"
+	"github.com/truechain/truechain-engineering-code/event"
+)
+
+// ProtocolName is the official short name of the snailtrue protocol used
+// during capability negotiation.
+const ProtocolName = "snailtrue"
+
+// ProtocolVersions are the supported versions of the snailtrue protocol
+// (first is primary).
+var ProtocolVersions = []uint{1}
+
+// ProtocolLengths are the number of implemented messages corresponding to
+// different protocol versions.
+var ProtocolLengths = []uint64{7}
+
+const ProtocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
+
+// snailtrue protocol message codes.
+const (
+	StatusMsg = 0x00
+
+	FruitMsg      = 0x01
+	SnailBlockMsg = 0x02
+
+	GetSnailBlockHeadersMsg = 0x03
+	SnailBlockHeadersMsg    = 0x04
+	GetSnailBlockBodiesMsg  = 0x05
+	SnailBlockBodiesMsg     = 0x06
+)
+
+// statusData is the network packet for the snailtrue status message. Unlike
+// etrue's statusData, TD here is the total difficulty of the snail chain,
+// so a peer can be judged ahead or behind on snail sync independently of
+// its fast-chain status.
+type statusData struct {
+	ProtocolVersion uint32
+	NetworkId       uint64
+	TD              *big.Int
+	CurrentBlock    common.Hash
+	GenesisBlock    common.Hash
+}
+
+// SnailPool is implemented by the local fruit/snail-block pool so the
+// snailtrue protocol manager can pull pending fruits and feed them newly
+// received ones.
+type SnailPool interface {
+	AddRemoteFruits([]*types.SnailBlock, bool) []error
+	//AddRemoteSnailBlocks([]*types.SnailBlock) []error
+	PendingFruits() map[common.Hash]*types.SnailBlock
+	SubscribeNewFruitEvent(chan<- types.NewFruitsEvent) event.Subscription
+	//SubscribeNewSnailBlockEvent(chan<- core.NewSnailBlocksEvent) event.Subscription
+	//AddRemoteRecords([]*types.PbftRecord) []error
+	//SubscribeNewRecordEvent(chan<- core.NewRecordsEvent) event.Subscription
+
+	RemovePendingFruitByFastHash(fasthash common.Hash)
+}
+
+// newSnailBlockData is the network packet for the snail-block propagation
+// message.
+type newSnailBlockData struct {
+	Block *types.SnailBlock
+	TD    *big.Int
+}
+
+// snailBlockBody represents the data content of a single snail block.
+type snailBlockBody struct {
+	Fruits []*types.SnailBlock
+	Signs  []*types.PbftSign
+}
+
+// snailBlockBodiesData is the network packet for snail block content
+// distribution.
+type snailBlockBodiesData []*snailBlockBody